@@ -0,0 +1,399 @@
+package supersimplesoup
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"maps"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrNoArticle = errors.New("no article content")
+
+// Article is the main content of a document.
+type Article struct {
+	Title     string    // the title of the article
+	Byline    string    // the author of the article
+	Published time.Time // the publish time, zero if not found
+	Image     string    // the URL of the lead image
+	Content   *Node     // the cleaned content, a detached div element node holding the copies of the content nodes
+	Text      string    // the plain text of the content, as InnerText
+}
+
+var (
+	unlikelyRegexp = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumbs|combx|comment|community|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|yom-remote`)
+	maybeRegexp    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveRegexp = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeRegexp = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+	bylineRegexp   = regexp.MustCompile(`(?i)byline|author|dateline|writtenby|p-author`)
+)
+
+// blockTags is the tags of the block element nodes, which make a div element node not a paragraph.
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "dl": true, "div": true, "fieldset": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true,
+	"table": true, "ul": true,
+}
+
+// ExtractArticle returns the main content of the document which the node belongs to, as Mozilla Readability.
+//
+// The element nodes are scored by the length and the commas of their paragraphs, the link density,
+// and the class and id names, and the content is the top scored element node with its related siblings.
+// The metadata and the lead image are resolved as AbsURL with the base, which may be nil.
+// The document is not changed. It returns ErrNoArticle if no content is found.
+func ExtractArticle(doc *Node, base *url.URL) (*Article, error) {
+	if doc == nil {
+		return nil, ErrNoArticle
+	}
+	doc = rootNode(doc)
+	base = documentBase(doc, base)
+	meta := Metadata(doc, base)
+	article := &Article{
+		Title:     articleTitle(doc, meta),
+		Byline:    articleByline(doc, meta),
+		Published: articlePublished(doc, meta),
+		Image:     meta.OpenGraph.Get("og:image"),
+	}
+
+	body := doc.Query("body")
+	if body == nil {
+		return nil, ErrNoArticle
+	}
+	body = body.Clone(true)
+	prepareArticle(body)
+	top, scores := scoreArticle(body)
+	if top == nil {
+		return nil, ErrNoArticle
+	}
+
+	content := NewElement("div")
+	threshold := math.Max(10, scores[top]*0.2)
+	if top == body {
+		// The content is right under body, which is not copied itself.
+		for _, c := range slices.Collect(body.Children()) {
+			content.AppendChild(c.Remove())
+		}
+	} else {
+		for _, sibling := range siblingsOf(top) {
+			if sibling == top || scores[sibling] >= threshold || isParagraphSibling(sibling) {
+				content.AppendChild(sibling.Remove())
+			}
+		}
+	}
+	cleanArticle(content, scores)
+	if strings.TrimSpace(content.FullText()) == "" {
+		return nil, ErrNoArticle
+	}
+	article.Content = content
+	article.Text = content.InnerText()
+	if article.Image == "" {
+		// The content is detached, so its image is resolved against the base of the document.
+		if img := content.Query("img"); img != nil {
+			if src, ok := lookupAttr(img, "src"); ok {
+				article.Image = resolveArticleURL(base, src)
+			}
+		}
+	} else {
+		article.Image = resolveArticleURL(base, article.Image)
+	}
+	return article, nil
+}
+
+// resolveArticleURL resolves the reference against the base resolved by documentBase, or returns it as it is if invalid.
+func resolveArticleURL(base *url.URL, ref string) string {
+	u, err := resolveRef(base, ref)
+	if err != nil {
+		return ref
+	}
+	return normalizeURL(u)
+}
+
+func articleTitle(doc *Node, meta *Meta) string {
+	if title := meta.OpenGraph.Get("og:title"); title != "" {
+		return title
+	}
+	if h1s := doc.QueryAll("h1"); len(h1s) == 1 {
+		if title := collapseSpace(h1s[0].FullText()); title != "" {
+			return title
+		}
+	}
+	return meta.Title
+}
+
+func articleByline(doc *Node, meta *Meta) string {
+	for e := range doc.Elements() {
+		if e.Data == "meta" && strings.EqualFold(e.Attribute("name"), "author") {
+			if author := strings.TrimSpace(e.Attribute("content")); author != "" {
+				return author
+			}
+		}
+	}
+	if author := meta.OpenGraph.Get("article:author"); author != "" {
+		return author
+	}
+	var byline string
+	doc.Walk(func(node *Node) error {
+		if !node.IsElementNode() {
+			return nil
+		}
+		rel := node.Attribute("rel")
+		itemprop := node.Attribute("itemprop")
+		if rel == "author" || strings.Contains(itemprop, "author") || bylineRegexp.MatchString(node.Class()+" "+node.ID()) {
+			if text := collapseSpace(node.FullText()); text != "" && len(text) < 100 {
+				byline = text
+				return SkipAll
+			}
+		}
+		return nil
+	})
+	return byline
+}
+
+var publishedLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02"}
+
+func articlePublished(doc *Node, meta *Meta) time.Time {
+	candidates := []string{meta.OpenGraph.Get("article:published_time")}
+	for _, item := range meta.Items {
+		candidates = append(candidates, item.Get("datePublished"))
+	}
+	for _, block := range meta.JSONLD {
+		candidates = append(candidates, jsonLDString(block.Data, "datePublished"))
+	}
+	for e := range doc.Elements() {
+		if e.Data == "time" {
+			candidates = append(candidates, e.Attribute("datetime"))
+		}
+	}
+	for _, s := range candidates {
+		s = strings.TrimSpace(s)
+		for _, layout := range publishedLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// jsonLDString returns the first string value of the key in the JSON-LD data, searching the nested objects and arrays.
+func jsonLDString(data any, key string) string {
+	switch v := data.(type) {
+	case map[string]any:
+		if s, ok := v[key].(string); ok {
+			return s
+		}
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			if s := jsonLDString(v[k], key); s != "" {
+				return s
+			}
+		}
+	case []any:
+		for _, c := range v {
+			if s := jsonLDString(c, key); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// prepareArticle removes the nodes which are never content and the unlikely candidates.
+func prepareArticle(body *Node) {
+	var remove []*Node
+	body.Walk(func(node *Node) error {
+		switch {
+		case node.Type == html.CommentNode:
+		case !node.IsElementNode() || node == body:
+			return nil
+		case node.Data == "script" || node.Data == "style" || node.Data == "noscript" || node.Data == "template":
+		case node.Data == "nav" || node.Data == "aside" || node.Data == "footer":
+		case node.Data != "article" && node.Data != "a" && node.Data != "main":
+			names := node.Class() + " " + node.ID()
+			if !unlikelyRegexp.MatchString(names) || maybeRegexp.MatchString(names) {
+				return nil
+			}
+		default:
+			return nil
+		}
+		remove = append(remove, node)
+		return SkipNode
+	})
+	for _, node := range remove {
+		node.Remove()
+	}
+}
+
+// scoreArticle returns the top scored candidate and the scores of the candidates.
+func scoreArticle(body *Node) (*Node, map[*Node]float64) {
+	scores := map[*Node]float64{}
+	var candidates []*Node
+	initialize := func(n *Node) {
+		if _, ok := scores[n]; ok {
+			return
+		}
+		score := classWeight(n)
+		switch n.Data {
+		case "div", "article", "main", "section":
+			score += 5
+		case "pre", "td", "blockquote":
+			score += 3
+		case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+			score -= 3
+		case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+			score -= 5
+		}
+		scores[n] = score
+		candidates = append(candidates, n)
+	}
+	body.Walk(func(node *Node) error {
+		if !node.IsElementNode() || !isParagraph(node) {
+			return nil
+		}
+		text := collapseSpace(node.FullText())
+		if len(text) < 25 {
+			return nil
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		level := 0
+		for a := node.ParentNode(); a != nil && a.IsElementNode() && level < 5; a = a.ParentNode() {
+			initialize(a)
+			switch level {
+			case 0:
+				scores[a] += score
+			case 1:
+				scores[a] += score / 2
+			default:
+				scores[a] += score / float64(level*3)
+			}
+			level++
+		}
+		return nil
+	})
+	var top *Node
+	for _, c := range candidates {
+		scores[c] *= 1 - linkDensity(c)
+		if top == nil || scores[c] > scores[top] {
+			top = c
+		}
+	}
+	// Prefer the parent which holds the content split into several candidates.
+	for top != nil && top != body {
+		parent := top.ParentNode()
+		if parent == nil || parent == body || scores[parent] < scores[top]*0.75 {
+			break
+		}
+		top = parent
+	}
+	return top, scores
+}
+
+// isParagraph reports whether the element node is a paragraph, including the div element node without block children.
+func isParagraph(n *Node) bool {
+	switch n.Data {
+	case "p", "pre", "td":
+		return true
+	case "div":
+		for e := range n.Elements() {
+			if blockTags[e.Data] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isParagraphSibling(n *Node) bool {
+	if !n.IsElementNode() || n.Data != "p" {
+		return false
+	}
+	text := collapseSpace(n.FullText())
+	density := linkDensity(n)
+	if len(text) > 80 {
+		return density < 0.25
+	}
+	return len(text) > 0 && density == 0 && strings.ContainsAny(text[len(text)-1:], ".!?。")
+}
+
+func siblingsOf(n *Node) []*Node {
+	parent := n.ParentNode()
+	if parent == nil {
+		return []*Node{n}
+	}
+	var siblings []*Node
+	for c := range parent.Children() {
+		siblings = append(siblings, c)
+	}
+	return siblings
+}
+
+// classWeight returns the weight of the class and id names, positive for the content and negative for the others.
+func classWeight(n *Node) float64 {
+	var weight float64
+	for _, name := range []string{n.Class(), n.ID()} {
+		if name == "" {
+			continue
+		}
+		if negativeRegexp.MatchString(name) {
+			weight -= 25
+		}
+		if positiveRegexp.MatchString(name) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity returns the ratio of the text length in the links to the text length of the node.
+func linkDensity(n *Node) float64 {
+	total := len(collapseSpace(n.FullText()))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	for e := range n.Elements() {
+		if e.Data == "a" {
+			links += len(collapseSpace(e.FullText()))
+		}
+	}
+	return float64(links) / float64(total)
+}
+
+// cleanArticle removes the forms, the embedded content, the boilerplate blocks and the presentational attributes.
+func cleanArticle(content *Node, scores map[*Node]float64) {
+	var remove []*Node
+	content.Walk(func(node *Node) error {
+		if !node.IsElementNode() || node == content {
+			return nil
+		}
+		switch node.Data {
+		case "form", "input", "button", "select", "textarea", "iframe", "object", "embed", "h1":
+			remove = append(remove, node)
+			return SkipNode
+		case "div", "section", "ul", "ol", "table":
+			weight := classWeight(node)
+			paragraphs := len(node.QueryAll("p"))
+			images := len(node.QueryAll("img"))
+			density := linkDensity(node)
+			text := collapseSpace(node.FullText())
+			if weight+scores[node] < 0 ||
+				(weight < 25 && density > 0.2 && len(text) < 200) || density > 0.5 ||
+				(node.Data != "ul" && node.Data != "ol" && images > 1 && paragraphs < images/2) {
+				remove = append(remove, node)
+				return SkipNode
+			}
+		}
+		for _, attr := range []string{"class", "id", "style", "align", "width", "height"} {
+			node.RemoveAttribute(attr)
+		}
+		return nil
+	})
+	for _, node := range remove {
+		node.Remove()
+	}
+}
@@ -0,0 +1,147 @@
+package supersimplesoup
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testArticleHTML = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Gardening tips | Example News</title>
+	<meta name="author" content="Jane Doe">
+	<meta property="article:published_time" content="2024-03-05T08:30:00Z">
+	<script>var tracking = true;</script>
+</head>
+<body>
+	<header class="site-header"><a href="/">Example News</a></header>
+	<nav><a href="/a">World</a> <a href="/b">Sports</a></nav>
+	<div id="main">
+		<div class="sidebar">
+			<ul><li><a href="/1">Popular story one</a></li><li><a href="/2">Popular story two</a></li></ul>
+		</div>
+		<div class="article-body">
+			<h1>Gardening tips</h1>
+			<p>Spring is the best time to plant, and the soil is finally warm enough to work with, so get outside.</p>
+			<img src="/img/garden.jpg" alt="Garden">
+			<p>Water your plants early in the morning, before the sun is high, so that less of the water evaporates.</p>
+			<div class="share-tools"><a href="/share/fb">Share</a> <a href="/share/tw">Tweet</a></div>
+			<p>Finally, remember to mulch, which keeps the weeds down, holds moisture and feeds the soil over time.</p>
+			<form><input name="email"><button>Subscribe</button></form>
+		</div>
+		<div class="comments">
+			<p>Great article, thanks for sharing these tips with us, I will try them out this weekend.</p>
+		</div>
+	</div>
+	<footer>Copyright Example News, all rights reserved, since forever, and so on and so on.</footer>
+</body>
+</html>`
+
+func TestExtractArticle(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testArticleHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := doc.HTML()
+	article, err := ExtractArticle(doc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if article.Title != "Gardening tips" || article.Byline != "Jane Doe" || article.Image != "/img/garden.jpg" {
+		t.Errorf("article, got %q %q %q", article.Title, article.Byline, article.Image)
+	}
+	if want := time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC); !article.Published.Equal(want) {
+		t.Errorf("published, want %v, got %v", want, article.Published)
+	}
+	for _, s := range []string{"Spring is the best time", "Water your plants", "remember to mulch"} {
+		if !strings.Contains(article.Text, s) {
+			t.Errorf("text, want contains %q, got %q", s, article.Text)
+		}
+	}
+	for _, s := range []string{"Popular story", "Great article", "Copyright", "Share", "Subscribe", "tracking", "World"} {
+		if strings.Contains(article.Text, s) {
+			t.Errorf("text, want not contains %q, got %q", s, article.Text)
+		}
+	}
+	if got := strings.Count(article.Text, "\n\n"); got != 2 {
+		t.Errorf("text paragraphs separator count, want %d, got %d", 2, got)
+	}
+	if article.Content.Parent != nil || len(article.Content.QueryAll("p")) != 3 || article.Content.Query("div", "class", "article-body") != nil {
+		t.Errorf("content, got %s", article.Content.HTML())
+	}
+	if doc.HTML() != before {
+		t.Errorf("document changed by ExtractArticle")
+	}
+}
+
+func TestExtractArticleMetadata(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<html><head><title>T</title>
+		<meta property="og:image" content="lead.png"><base href="https://example.com/news/">
+		<script type="application/ld+json">{"@graph": [{"@type": "NewsArticle", "datePublished": "2023-12-31"}]}</script></head>
+		<body><div><p>This is the only paragraph of the article, and it is long enough to be scored.</p>
+		<span class="byline">By Sam</span></div></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := ExtractArticle(doc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if article.Title != "T" || article.Byline != "By Sam" || article.Image != "https://example.com/news/lead.png" || !article.Published.Equal(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("article, got %+v", article)
+	}
+
+	empty, _ := Parse(strings.NewReader(`<nav><a href="/">home</a></nav>`))
+	if _, err := ExtractArticle(empty, nil); !errors.Is(err, ErrNoArticle) {
+		t.Errorf("extract empty article, want ErrNoArticle, got %v", err)
+	}
+}
+
+func TestExtractArticleBody(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<html><body><h1>Title</h1>
+		<p>The first paragraph is right under body, and it is long enough to be scored, with a comma.</p>
+		<p>The second paragraph is right under body as well, and it is long enough, with a comma too.</p></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := ExtractArticle(doc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if article.Content.Query("body") != nil || len(article.Content.QueryAll("p")) != 2 || article.Content.Parent != nil {
+		t.Errorf("content, got %s", article.Content.HTML())
+	}
+	if want := "The first paragraph"; !strings.HasPrefix(article.Text, want) || strings.Count(article.Text, "\n\n") != 1 {
+		t.Errorf("text, want starts with %q and 2 paragraphs, got %q", want, article.Text)
+	}
+}
+
+func TestExtractArticleImage(t *testing.T) {
+	const body = `<body><div><p>This is the only paragraph of the article, and it is long enough to be scored.</p><img src="lead.png"></div></body>`
+	doc, err := Parse(strings.NewReader(`<html><head><base href="https://example.com/news/"></head>` + body + `</html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := ExtractArticle(doc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/news/lead.png"; article.Image != want {
+		t.Errorf("image with base element, want %q, got %q", want, article.Image)
+	}
+
+	doc, err = Parse(strings.NewReader(`<html>` + body + `</html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/blog/post")
+	if article, err = ExtractArticle(doc, base); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/blog/lead.png"; article.Image != want {
+		t.Errorf("image with base, want %q, got %q", want, article.Image)
+	}
+}
@@ -0,0 +1,43 @@
+package supersimplesoup
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Clone returns a detached copy of this node, including its data and attributes.
+//
+// If deep is true, all the descendant nodes are copied as well, so the copy renders the same HTML as this node.
+// The copy shares nothing with this node, so it can be mutated or used by another goroutine independently.
+func (n *Node) Clone(deep bool) *Node {
+	if n == nil {
+		return nil
+	}
+	c := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+	}
+	if n.Attr != nil {
+		c.Attr = make([]html.Attribute, len(n.Attr))
+		copy(c.Attr, n.Attr)
+	}
+	if deep {
+		for child := n.FirstChildNode(); child != nil; child = child.NextSiblingNode() {
+			c.AppendChild((*html.Node)(child.Clone(true)))
+		}
+	}
+	return (*Node)(c)
+}
+
+// Clone returns the deep copies of each node of this nodes.
+func (ns Nodes) Clone() Nodes {
+	if ns == nil {
+		return nil
+	}
+	cs := make(Nodes, 0, len(ns))
+	for _, n := range ns {
+		cs = append(cs, n.Clone(true))
+	}
+	return cs
+}
@@ -0,0 +1,42 @@
+package supersimplesoup
+
+import (
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	ul := root.Query("ul")
+	c := ul.Clone(true)
+	if c.ParentNode() != nil || c.PrevSiblingNode() != nil || c.NextSiblingNode() != nil {
+		t.Errorf("clone is not detached")
+	}
+	if want, got := ul.HTML(), c.HTML(); want != got {
+		t.Errorf("clone html, want %q, got %q", want, got)
+	}
+
+	c.Query("a").SetAttribute("id", "changed").SetText("changed")
+	if got := root.Query("a").ID(); got != "a-id-1" {
+		t.Errorf("source attribute id changed by clone, got %q", got)
+	}
+	if got := root.Query("a").Text(); got != "a-text-1" {
+		t.Errorf("source text changed by clone, got %q", got)
+	}
+
+	shallow := ul.Clone(false)
+	if shallow.FirstChildNode() != nil {
+		t.Errorf("shallow clone has child nodes")
+	}
+	if want, got := `<ul id="ul-id-1" title="ul-title-1" class="ul-class-1"></ul>`, shallow.HTML(); want != got {
+		t.Errorf("shallow clone html, want %q, got %q", want, got)
+	}
+
+	lis := root.QueryAll("li").Clone()
+	if len(lis) != 4 {
+		t.Fatalf("clone nodes count, want %d, got %d", 4, len(lis))
+	}
+	for i, li := range lis {
+		if li.ParentNode() != nil || li.ID() != root.QueryAll("li")[i].ID() {
+			t.Errorf("clone nodes %d is not a detached copy", i)
+		}
+	}
+}
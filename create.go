@@ -0,0 +1,36 @@
+package supersimplesoup
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// NewDocument returns a new empty document node, which is the root of a tree.
+func NewDocument() *Node {
+	return &Node{Type: html.DocumentNode}
+}
+
+// NewElement returns a new detached element node with the tag and optional attribute key and value pairs.
+//
+// A trailing key without value makes an attribute with empty value.
+func NewElement(tag string, attrs ...string) *Node {
+	n := &Node{Type: html.ElementNode, Data: tag, DataAtom: atom.Lookup([]byte(tag))}
+	for i := 0; i < len(attrs); i += 2 {
+		attr := html.Attribute{Key: attrs[i]}
+		if i+1 < len(attrs) {
+			attr.Val = attrs[i+1]
+		}
+		n.Attr = append(n.Attr, attr)
+	}
+	return n
+}
+
+// NewText returns a new detached text node with the text.
+func NewText(text string) *Node {
+	return &Node{Type: html.TextNode, Data: text}
+}
+
+// NewComment returns a new detached comment node with the comment.
+func NewComment(comment string) *Node {
+	return &Node{Type: html.CommentNode, Data: comment}
+}
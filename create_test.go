@@ -0,0 +1,54 @@
+package supersimplesoup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewElement(t *testing.T) {
+	doc := NewDocument()
+	body := NewElement("body")
+	doc.AppendChild(NewElement("html").AppendChild(body))
+	body.AppendChild(NewComment("c")).
+		AppendChild(NewElement("a", "href", "/x", "hidden").AppendChild(NewText("a & b")))
+	if want, got := `<html><body><!--c--><a href="/x" hidden="">a &amp; b</a></body></html>`, doc.HTML(); want != got {
+		t.Errorf("document html, want %q, got %q", want, got)
+	}
+	if node := doc.Query("a", "href", "/x"); node == nil || node.Text() != "a & b" {
+		t.Errorf("query built element, got %v", node)
+	}
+}
+
+func TestParseFragment(t *testing.T) {
+	ns, err := ParseFragment(strings.NewReader(`<p>a</p>b<td>c</td>`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range ns {
+		if n.ParentNode() != nil {
+			t.Errorf("fragment node %q is not detached", n.Data)
+		}
+		got = append(got, n.HTML())
+	}
+	if want := "<p>a</p>|bc"; strings.Join(got, "|") != want {
+		t.Errorf("fragment html, want %q, got %q", want, strings.Join(got, "|"))
+	}
+
+	ns, err = ParseFragment(strings.NewReader(`<td>c</td>`), NewElement("tr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 1 || ns[0].HTML() != "<td>c</td>" {
+		t.Errorf("fragment in tr context, got %v", ns)
+	}
+
+	ul := parseBody(t, `<ul></ul>`).Query("ul")
+	ns, _ = ParseFragment(strings.NewReader(`<li>1</li><li>2</li>`), ul)
+	for _, n := range ns {
+		ul.AppendChild(n)
+	}
+	if want, got := `<ul><li>1</li><li>2</li></ul>`, ul.HTML(); want != got {
+		t.Errorf("inserted fragment html, want %q, got %q", want, got)
+	}
+}
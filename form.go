@@ -0,0 +1,491 @@
+package supersimplesoup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Form is the state of a form element node and its controls, which is filled and submitted without changing the tree.
+type Form struct {
+	node      *Node
+	controls  []*formControl
+	submitter *formControl
+}
+
+type formControl struct {
+	node     *Node
+	tag      string // input, select, textarea or button
+	typ      string // the type of input or button
+	name     string
+	value    string
+	checked  bool
+	disabled bool
+	options  []*formOption
+	filename string
+	content  []byte
+}
+
+type formOption struct {
+	value    string
+	selected bool
+	disabled bool
+}
+
+type formEntry struct {
+	name    string
+	value   string
+	file    bool
+	content []byte
+}
+
+// Form returns the form of the form element node, or of the nearest ancestor form element node.
+//
+// The controls are the input, select, textarea and button element nodes owned by the form,
+// including the ones outside the form element node associated with it by the form attribute.
+func (n *Node) Form() (*Form, error) {
+	if n == nil {
+		return nil, fmt.Errorf("not allow to find form on a blank node")
+	}
+	form := n
+	for form != nil && !(form.IsElementNode() && form.Data == "form") {
+		form = form.ParentNode()
+	}
+	if form == nil {
+		return nil, fmt.Errorf("not found form element of `%s`", n.Data)
+	}
+	f := &Form{node: form}
+	for e := range rootNode(form).Elements() {
+		switch e.Data {
+		case "input", "select", "textarea", "button":
+		default:
+			continue
+		}
+		if formOwner(e) != form {
+			continue
+		}
+		f.controls = append(f.controls, newFormControl(e))
+	}
+	return f, nil
+}
+
+// formOwner returns the form element node which the control belongs to.
+// The control with the form attribute belongs to the form element node of that id only, or none if it is empty.
+func formOwner(e *Node) *Node {
+	if id, ok := lookupAttr(e, "form"); ok {
+		if id == "" {
+			return nil
+		}
+		for f := range rootNode(e).Elements() {
+			if f.Data == "form" && f.ID() == id {
+				return f
+			}
+		}
+		return nil
+	}
+	for p := e.ParentNode(); p != nil; p = p.ParentNode() {
+		if p.IsElementNode() && p.Data == "form" {
+			return p
+		}
+	}
+	return nil
+}
+
+func newFormControl(e *Node) *formControl {
+	c := &formControl{node: e, tag: e.Data, name: e.Attribute("name"), disabled: isDisabled(e)}
+	switch e.Data {
+	case "input":
+		c.typ = strings.ToLower(e.Attribute("type"))
+		switch c.typ {
+		case "checkbox", "radio":
+			_, c.checked = lookupAttr(e, "checked")
+			c.value = "on"
+			if v, ok := lookupAttr(e, "value"); ok {
+				c.value = v
+			}
+		case "file":
+		default:
+			c.value = e.Attribute("value")
+		}
+		if c.typ == "" {
+			c.typ = "text"
+		}
+	case "button":
+		c.typ = strings.ToLower(e.Attribute("type"))
+		if c.typ != "reset" && c.typ != "button" {
+			c.typ = "submit"
+		}
+		c.value = e.Attribute("value")
+	case "textarea":
+		c.value = strings.ReplaceAll(strings.ReplaceAll(e.FullText(), "\r\n", "\n"), "\r", "\n")
+	case "select":
+		_, multiple := lookupAttr(e, "multiple")
+		c.typ = "select-one"
+		if multiple {
+			c.typ = "select-multiple"
+		}
+		for o := range e.Elements() {
+			if o.Data != "option" {
+				continue
+			}
+			opt := &formOption{value: collapseSpace(o.FullText())}
+			if v, ok := lookupAttr(o, "value"); ok {
+				opt.value = v
+			}
+			_, opt.selected = lookupAttr(o, "selected")
+			_, opt.disabled = lookupAttr(o, "disabled")
+			if p := o.ParentNode(); p.Data == "optgroup" {
+				_, disabled := lookupAttr(p, "disabled")
+				opt.disabled = opt.disabled || disabled
+			}
+			c.options = append(c.options, opt)
+		}
+		if !multiple {
+			c.normalizeSelect()
+		}
+	}
+	return c
+}
+
+// normalizeSelect keeps the last selected option of the select-one, or selects the first enabled option if none.
+func (c *formControl) normalizeSelect() {
+	last := -1
+	for i, opt := range c.options {
+		if opt.selected {
+			last = i
+		}
+		opt.selected = false
+	}
+	if last < 0 {
+		last = slices.IndexFunc(c.options, func(opt *formOption) bool { return !opt.disabled })
+	}
+	if last >= 0 {
+		c.options[last].selected = true
+	}
+}
+
+// isDisabled reports whether the control is disabled by itself or an ancestor fieldset element node,
+// except in the first legend element node of the fieldset.
+func isDisabled(e *Node) bool {
+	if _, ok := lookupAttr(e, "disabled"); ok {
+		return true
+	}
+	child := e
+	for p := e.ParentNode(); p != nil; child, p = p, p.ParentNode() {
+		if !p.IsElementNode() || p.Data != "fieldset" {
+			continue
+		}
+		if _, ok := lookupAttr(p, "disabled"); !ok {
+			continue
+		}
+		if child.Data != "legend" || firstLegend(p) != child {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLegend(fieldset *Node) *Node {
+	for c := range fieldset.Children() {
+		if c.IsElementNode() && c.Data == "legend" {
+			return c
+		}
+	}
+	return nil
+}
+
+func lookupAttr(n *Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// Node returns the form element node.
+func (f *Form) Node() *Node {
+	return f.node
+}
+
+// Method returns the submission method in upper case, GET or POST, which is overridden by the formmethod attribute of the clicked button.
+func (f *Form) Method() string {
+	method := f.node.Attribute("method")
+	if f.submitter != nil {
+		if m, ok := lookupAttr(f.submitter.node, "formmethod"); ok {
+			method = m
+		}
+	}
+	if strings.EqualFold(strings.TrimSpace(method), "post") {
+		return http.MethodPost
+	}
+	return http.MethodGet
+}
+
+// Enctype returns the encoding type of the POST submission, which is overridden by the formenctype attribute of the clicked button.
+//
+// It returns one of application/x-www-form-urlencoded, multipart/form-data and text/plain.
+func (f *Form) Enctype() string {
+	enctype := f.node.Attribute("enctype")
+	if f.submitter != nil {
+		if e, ok := lookupAttr(f.submitter.node, "formenctype"); ok {
+			enctype = e
+		}
+	}
+	switch enctype = strings.ToLower(strings.TrimSpace(enctype)); enctype {
+	case "multipart/form-data", "text/plain":
+		return enctype
+	}
+	return "application/x-www-form-urlencoded"
+}
+
+// Action returns the submission URL resolved against the href of the base element node and then the base URL of the document,
+// which is overridden by the formaction attribute of the clicked button. The empty action means the base URL.
+//
+// The base URL may be nil, which leaves the action relative unless the document has a base element node.
+func (f *Form) Action(base *url.URL) (*url.URL, error) {
+	action := f.node.Attribute("action")
+	if f.submitter != nil {
+		if a, ok := lookupAttr(f.submitter.node, "formaction"); ok {
+			action = a
+		}
+	}
+	return resolveURL(f.node, base, action)
+}
+
+// Fields returns the distinct names of the controls in tree order.
+func (f *Form) Fields() (names []string) {
+	for _, c := range f.controls {
+		if c.name != "" && !containsString(names, c.name) {
+			names = append(names, c.name)
+		}
+	}
+	return
+}
+
+func (f *Form) named(name string) (controls []*formControl) {
+	for _, c := range f.controls {
+		if c.name == name {
+			controls = append(controls, c)
+		}
+	}
+	return
+}
+
+// Get returns the first value of the named field that would be submitted, or empty string if none.
+func (f *Form) Get(name string) string {
+	for _, e := range f.entries() {
+		if e.name == name {
+			return e.value
+		}
+	}
+	return ""
+}
+
+// Set sets the values of the named field.
+//
+// The checkbox and radio controls are checked if their values are in values, and unchecked otherwise.
+// The options of the select control are selected if their values are in values, and unselected otherwise.
+// The other controls of the same name are assigned the values in order.
+// It returns an error and leaves the form unchanged if the field is not found, or a value matches no control or option.
+func (f *Form) Set(name string, values ...string) error {
+	controls := f.named(name)
+	if len(controls) == 0 {
+		return fmt.Errorf("not found form field `%s`", name)
+	}
+	// Check the values before changing any control, so the form is unchanged if it fails.
+	if err := setControls(controls, values, false); err != nil {
+		return fmt.Errorf("%w of form field `%s`", err, name)
+	}
+	return setControls(controls, values, true)
+}
+
+func setControls(controls []*formControl, values []string, apply bool) error {
+	matched := make([]bool, len(values))
+	i := 0
+	for _, c := range controls {
+		switch {
+		case c.typ == "checkbox" || c.typ == "radio":
+			j := slices.Index(values, c.value)
+			checked := j >= 0 && (c.typ == "checkbox" || !matched[j])
+			if checked {
+				matched[j] = true
+			}
+			if apply {
+				c.checked = checked
+			}
+		case c.tag == "select":
+			if c.typ == "select-one" && len(values) > 1 {
+				return fmt.Errorf("too many values %q", values)
+			}
+			for _, opt := range c.options {
+				j := slices.Index(values, opt.value)
+				if j >= 0 {
+					matched[j] = true
+				}
+				if apply {
+					opt.selected = j >= 0
+				}
+			}
+		case c.typ == "submit" || c.typ == "reset" || c.typ == "button" || c.typ == "image" || c.typ == "file":
+		default:
+			if i < len(values) {
+				if apply {
+					c.value = values[i]
+				}
+				matched[i] = true
+				i++
+			}
+		}
+	}
+	if j := slices.Index(matched, false); j >= 0 {
+		return fmt.Errorf("not matched value `%s`", values[j])
+	}
+	return nil
+}
+
+// SetFile sets the file of the named file input control, which is submitted by the multipart/form-data encoding.
+func (f *Form) SetFile(name, filename string, content []byte) error {
+	for _, c := range f.named(name) {
+		if c.typ == "file" {
+			c.filename, c.content = filename, content
+			return nil
+		}
+	}
+	return fmt.Errorf("not found file form field `%s`", name)
+}
+
+// Click makes the named submit button the submitter, whose name and value are submitted with the form.
+// The empty name clears the submitter.
+func (f *Form) Click(name string) error {
+	if name == "" {
+		f.submitter = nil
+		return nil
+	}
+	for _, c := range f.named(name) {
+		if c.typ == "submit" && !c.disabled {
+			f.submitter = c
+			return nil
+		}
+	}
+	return fmt.Errorf("not found submit button `%s`", name)
+}
+
+// entries returns the name and value pairs to submit in tree order, as the HTML form submission rules.
+func (f *Form) entries() (entries []formEntry) {
+	for _, c := range f.controls {
+		if c.disabled || c.name == "" {
+			continue
+		}
+		switch {
+		case c.typ == "submit":
+			if c == f.submitter {
+				entries = append(entries, formEntry{name: c.name, value: c.value})
+			}
+		case c.typ == "reset" || c.typ == "button" || c.typ == "image":
+		case c.typ == "checkbox" || c.typ == "radio":
+			if c.checked {
+				entries = append(entries, formEntry{name: c.name, value: c.value})
+			}
+		case c.typ == "file":
+			entries = append(entries, formEntry{name: c.name, value: c.filename, file: true, content: c.content})
+		case c.tag == "select":
+			for _, opt := range c.options {
+				if opt.selected && !opt.disabled {
+					entries = append(entries, formEntry{name: c.name, value: opt.value})
+				}
+			}
+		case c.tag == "textarea":
+			entries = append(entries, formEntry{name: c.name, value: strings.ReplaceAll(c.value, "\n", "\r\n")})
+		default:
+			entries = append(entries, formEntry{name: c.name, value: c.value})
+		}
+	}
+	return
+}
+
+// encode returns the URL encoded name and value pairs to submit in tree order.
+func (f *Form) encode() string {
+	var buf strings.Builder
+	for _, e := range f.entries() {
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(url.QueryEscape(e.name) + "=" + url.QueryEscape(e.value))
+	}
+	return buf.String()
+}
+
+// Values returns the name and value pairs to submit. The file input controls submit their filenames.
+func (f *Form) Values() url.Values {
+	values := url.Values{}
+	for _, e := range f.entries() {
+		values.Add(e.name, e.value)
+	}
+	return values
+}
+
+// WriteMultipart writes the name and value pairs and the files to submit to w as multipart/form-data,
+// and returns the content type with the boundary.
+func (f *Form) WriteMultipart(w io.Writer) (string, error) {
+	mw := multipart.NewWriter(w)
+	for _, e := range f.entries() {
+		if !e.file {
+			if err := mw.WriteField(e.name, e.value); err != nil {
+				return "", err
+			}
+			continue
+		}
+		part, err := mw.CreateFormFile(e.name, e.value)
+		if err != nil {
+			return "", err
+		}
+		if _, err := part.Write(e.content); err != nil {
+			return "", err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+	return mw.FormDataContentType(), nil
+}
+
+// Request returns the HTTP request submitting the form, with the action resolved against the base URL of the document.
+//
+// The GET submission replaces the query of the action URL with the values, and the POST submission
+// sends the values in the body encoded by the enctype.
+func (f *Form) Request(base *url.URL) (*http.Request, error) {
+	action, err := f.Action(base)
+	if err != nil {
+		return nil, err
+	}
+	if f.Method() == http.MethodGet {
+		action.RawQuery = f.encode()
+		return http.NewRequest(http.MethodGet, action.String(), nil)
+	}
+	var body bytes.Buffer
+	contentType := f.Enctype()
+	switch contentType {
+	case "multipart/form-data":
+		if contentType, err = f.WriteMultipart(&body); err != nil {
+			return nil, err
+		}
+	case "text/plain":
+		for _, e := range f.entries() {
+			body.WriteString(e.name + "=" + e.value + "\r\n")
+		}
+	default:
+		body.WriteString(f.encode())
+	}
+	req, err := http.NewRequest(http.MethodPost, action.String(), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
@@ -0,0 +1,188 @@
+package supersimplesoup
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFormHTML = `<base href="/app/">
+<form id="login" action="submit?x=1" method="post">
+	<input name="user" value="alice">
+	<input type="password" name="pass">
+	<input type="checkbox" name="remember" checked>
+	<input type="checkbox" name="tag" value="a">
+	<input type="checkbox" name="tag" value="b" checked>
+	<input type="radio" name="mode" value="fast">
+	<input type="radio" name="mode" value="slow" checked>
+	<input name="off" value="x" disabled>
+	<fieldset disabled>
+		<legend><input name="legend" value="y"></legend>
+		<input name="fieldset" value="z">
+	</fieldset>
+	<select name="color">
+		<option disabled>pick</option>
+		<option value="r">Red</option>
+		<option>Green</option>
+	</select>
+	<select name="size" multiple>
+		<option selected>S</option>
+		<option>M</option>
+		<option selected disabled>L</option>
+	</select>
+	<textarea name="bio">
+line1
+line2</textarea>
+	<input type="file" name="avatar">
+	<input type="reset" name="reset">
+	<button name="go" value="1">Go</button>
+	<button name="save" value="2" formmethod="get" formaction="/save">Save</button>
+</form>
+<input name="outside" value="o" form="login">
+<input name="ignored" value="i">`
+
+func getTestForm(t *testing.T) *Form {
+	t.Helper()
+	input, err := parseBody(t, testFormHTML).Find("input", "name", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := input.Form()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return form
+}
+
+func TestForm(t *testing.T) {
+	form := getTestForm(t)
+	want := url.Values{
+		"user":     {"alice"},
+		"pass":     {""},
+		"remember": {"on"},
+		"tag":      {"b"},
+		"mode":     {"slow"},
+		"legend":   {"y"},
+		"color":    {"r"},
+		"size":     {"S"},
+		"bio":      {"line1\r\nline2"},
+		"avatar":   {""},
+		"outside":  {"o"},
+	}
+	if got := form.Values(); !reflect.DeepEqual(want, got) {
+		t.Errorf("values, want %v, got %v", want, got)
+	}
+	fields := []string{"user", "pass", "remember", "tag", "mode", "off", "legend", "fieldset", "color", "size", "bio", "avatar", "reset", "go", "save", "outside"}
+	if got := form.Fields(); !reflect.DeepEqual(fields, got) {
+		t.Errorf("fields, want %v, got %v", fields, got)
+	}
+	if form.Method() != http.MethodPost || form.Enctype() != "application/x-www-form-urlencoded" || form.Node().ID() != "login" {
+		t.Errorf("method and enctype, got %s and %s", form.Method(), form.Enctype())
+	}
+	base, _ := url.Parse("http://example.com/index.html")
+	if action, err := form.Action(base); err != nil || action.String() != "http://example.com/app/submit?x=1" {
+		t.Errorf("action, want %s, got %v %v", "http://example.com/app/submit?x=1", action, err)
+	}
+	if action, err := form.Action(nil); err != nil || action.String() != "/app/submit?x=1" {
+		t.Errorf("action without base, want %s, got %v %v", "/app/submit?x=1", action, err)
+	}
+
+	form, err := parseBody(t, `<form><input name="a" value="1"><input name="b" value="2" form=""></form><form id=""></form>`).Query("input").Form()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := (url.Values{"a": {"1"}}), form.Values(); !reflect.DeepEqual(want, got) {
+		t.Errorf("values with empty form attribute, want %v, got %v", want, got)
+	}
+	if _, err := root.Form(); err == nil {
+		t.Errorf("form of non form node, want error, got nil")
+	}
+}
+
+func TestFormSet(t *testing.T) {
+	form := getTestForm(t)
+	tests := []struct {
+		name   string
+		values []string
+		ok     bool
+	}{
+		{"user", []string{"bob"}, true},
+		{"tag", []string{"a", "b"}, true},
+		{"mode", []string{"fast"}, true},
+		{"remember", nil, true},
+		{"color", []string{"Green"}, true},
+		{"size", []string{"M", "S"}, true},
+		{"mode", []string{"medium"}, false},
+		{"color", []string{"r", "Green"}, false},
+		{"missing", []string{"x"}, false},
+	}
+	for _, test := range tests {
+		if err := form.Set(test.name, test.values...); (err == nil) != test.ok {
+			t.Errorf("set `%s` %v, want ok %v, got %v", test.name, test.values, test.ok, err)
+		}
+	}
+	values := form.Values()
+	if values.Get("user") != "bob" || !reflect.DeepEqual(values["tag"], []string{"a", "b"}) || form.Get("mode") != "fast" || values.Has("remember") ||
+		values.Get("color") != "Green" || !reflect.DeepEqual(values["size"], []string{"S", "M"}) {
+		t.Errorf("values after set, got %v", values)
+	}
+}
+
+func TestFormSubmit(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got, body = r, string(b)
+	}))
+	defer server.Close()
+
+	submit := func(form *Form) {
+		t.Helper()
+		base, _ := url.Parse(server.URL + "/index.html")
+		req, err := form.Request(base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	form := getTestForm(t)
+	form.Set("pass", "secret")
+	if err := form.Click("go"); err != nil {
+		t.Fatal(err)
+	}
+	submit(form)
+	if got.Method != http.MethodPost || got.URL.Path != "/app/submit" || got.URL.RawQuery != "x=1" {
+		t.Errorf("post request, got %s %s", got.Method, got.URL)
+	}
+	if want := "user=alice&pass=secret&remember=on&tag=b&mode=slow&legend=y&color=r&size=S&bio=line1%0D%0Aline2&avatar=&go=1&outside=o"; body != want {
+		t.Errorf("post body, want %s, got %s", want, body)
+	}
+
+	form.Click("save")
+	submit(form)
+	if got.Method != http.MethodGet || got.URL.Path != "/save" || got.URL.Query().Get("save") != "2" || got.URL.Query().Has("go") {
+		t.Errorf("get request, got %s %s", got.Method, got.URL)
+	}
+
+	form.Click("")
+	form.node.SetAttribute("enctype", "multipart/form-data")
+	form.SetFile("avatar", "me.png", []byte("PNG"))
+	submit(form)
+	got.Body = io.NopCloser(strings.NewReader(body))
+	if err := got.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	if got.FormValue("pass") != "secret" || got.MultipartForm.File["avatar"][0].Filename != "me.png" || got.MultipartForm.File["avatar"][0].Size != 3 {
+		t.Errorf("multipart request, got %v", got.MultipartForm)
+	}
+}
@@ -1,5 +1,8 @@
 module github.com/chamzzzzzz/supersimplesoup
 
-go 1.19
+go 1.24
 
-require golang.org/x/net v0.4.0
+require (
+	golang.org/x/net v0.4.0
+	golang.org/x/text v0.5.0
+)
@@ -0,0 +1,53 @@
+package supersimplesoup
+
+import (
+	"golang.org/x/net/html"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// documents holds the information recorded by the parser for the documents, one entry for each document.
+// It is keyed by the weak pointers so that it does not keep the documents alive,
+// and the entry is deleted once its document is garbage collected.
+var documents sync.Map
+
+// documentInfo is the information recorded by the parser for a document.
+// The spans are keyed by the weak pointers to the element nodes as well, so they do not keep the detached nodes alive.
+type documentInfo struct {
+	spans map[weak.Pointer[html.Node]]nodeSpan
+}
+
+func setDocumentInfo(doc *Node, info *documentInfo) {
+	key := weak.Make((*html.Node)(doc))
+	if _, loaded := documents.Swap(key, info); !loaded {
+		runtime.AddCleanup((*html.Node)(doc), func(key weak.Pointer[html.Node]) {
+			documents.Delete(key)
+		}, key)
+	}
+}
+
+func getDocumentInfo(doc *Node) *documentInfo {
+	if v, ok := documents.Load(weak.Make((*html.Node)(doc))); ok {
+		return v.(*documentInfo)
+	}
+	return nil
+}
+
+// forgetNode deletes the information recorded for the node, whose content is replaced,
+// which is the document information if the node is a document.
+func forgetNode(n *Node) {
+	root := rootNode(n)
+	if root == n {
+		documents.Delete(weak.Make((*html.Node)(n)))
+	} else if info := getDocumentInfo(root); info != nil {
+		delete(info.spans, weak.Make((*html.Node)(n)))
+	}
+}
+
+func rootNode(n *Node) *Node {
+	for n.Parent != nil {
+		n = n.ParentNode()
+	}
+	return n
+}
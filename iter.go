@@ -0,0 +1,113 @@
+package supersimplesoup
+
+import (
+	"golang.org/x/net/html"
+	"iter"
+)
+
+// Descendants returns an iterator over all the descendant nodes of this node in depth first order, not including this node.
+func (n *Node) Descendants() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		if n == nil {
+			return
+		}
+		top := (*html.Node)(n)
+		for c := top.FirstChild; c != nil; {
+			if !yield((*Node)(c)) {
+				return
+			}
+			if c.FirstChild != nil {
+				c = c.FirstChild
+				continue
+			}
+			for c != top && c.NextSibling == nil {
+				c = c.Parent
+			}
+			if c == top {
+				return
+			}
+			c = c.NextSibling
+		}
+	}
+}
+
+// Elements returns an iterator over all the descendant element nodes of this node in depth first order, not including this node.
+func (n *Node) Elements() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		for node := range n.Descendants() {
+			if node.IsElementNode() && !yield(node) {
+				return
+			}
+		}
+	}
+}
+
+// Children returns an iterator over all the direct child nodes of this node.
+func (n *Node) Children() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		if n == nil {
+			return
+		}
+		for c := n.FirstChildNode(); c != nil; c = c.NextSiblingNode() {
+			if !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+// Ancestors returns an iterator over all the ancestor nodes of this node, from the parent node up to the root node.
+func (n *Node) Ancestors() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		if n == nil {
+			return
+		}
+		for p := n.ParentNode(); p != nil; p = p.ParentNode() {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// FollowingSiblings returns an iterator over all the next sibling nodes of this node, from the nearest one.
+func (n *Node) FollowingSiblings() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		if n == nil {
+			return
+		}
+		for s := n.NextSiblingNode(); s != nil; s = s.NextSiblingNode() {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// PrecedingSiblings returns an iterator over all the previous sibling nodes of this node, from the nearest one.
+func (n *Node) PrecedingSiblings() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		if n == nil {
+			return
+		}
+		for s := n.PrevSiblingNode(); s != nil; s = s.PrevSiblingNode() {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// QueryIter returns an iterator over the child element nodes matched by the specified tag and optional attribute key and value pairs of this node.
+//
+// Unlike QueryAll, the nodes are matched lazily, so breaking the loop early stops the traversal.
+func (n *Node) QueryIter(tag string, attrkv ...string) iter.Seq[*Node] {
+	conds := mustPlainAttrs(attrkv)
+	return func(yield func(*Node) bool) {
+		for node := range n.Descendants() {
+			if match(node, tag, conds) && !yield(node) {
+				return
+			}
+		}
+	}
+}
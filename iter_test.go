@@ -0,0 +1,77 @@
+package supersimplesoup
+
+import (
+	"iter"
+	"reflect"
+	"testing"
+)
+
+func collectIDs(seq iter.Seq[*Node]) (ids []string) {
+	for node := range seq {
+		if node.IsElementNode() {
+			ids = append(ids, node.ID())
+		}
+	}
+	return
+}
+
+func TestDescendants(t *testing.T) {
+	want := 0
+	Walk(root, func(node *Node) error {
+		if node != root {
+			want++
+		}
+		return nil
+	})
+	got := 0
+	for range root.Descendants() {
+		got++
+	}
+	if want != got {
+		t.Errorf("descendant node count, want %d, got %d", want, got)
+	}
+
+	li := root.Query("li", "id", "li-id-2")
+	if want, got := []string{"a-id-3", "a-id-4"}, collectIDs(li.Descendants()); !reflect.DeepEqual(want, got) {
+		t.Errorf("`%s` descendant element ids, want %v, got %v", "li-id-2", want, got)
+	}
+	if got := len(collectIDs(root.Elements())); got != 19 {
+		t.Errorf("element node count, want %d, got %d", 19, got)
+	}
+}
+
+func TestIterAxes(t *testing.T) {
+	a := root.Query("a", "id", "a-id-6")
+	li := root.Query("li", "id", "li-id-2")
+	tests := []struct {
+		name string
+		seq  iter.Seq[*Node]
+		want []string
+	}{
+		{"children", li.Children(), []string{"a-id-3", "a-id-4"}},
+		{"ancestors", a.Ancestors(), []string{"li-id-3", "ul-id-2", "", "", ""}},
+		{"following-siblings", li.FollowingSiblings(), nil},
+		{"preceding-siblings", li.PrecedingSiblings(), []string{"li-id-1"}},
+		{"query-iter", root.QueryIter("a", "class", "a-class-2", "href", "$=7"), []string{"a-id-7"}},
+		{"nil", (*Node)(nil).Descendants(), nil},
+	}
+
+	for _, test := range tests {
+		if got := collectIDs(test.seq); !reflect.DeepEqual(test.want, got) {
+			t.Errorf("`%s` element ids, want %v, got %v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestQueryIterBreak(t *testing.T) {
+	var got []string
+	for node := range root.QueryIter("a") {
+		got = append(got, node.ID())
+		if len(got) == 3 {
+			break
+		}
+	}
+	if want := []string{"a-id-1", "a-id-2", "a-id-3"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("`%s` element ids, want %v, got %v", "a", want, got)
+	}
+}
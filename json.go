@@ -0,0 +1,188 @@
+package supersimplesoup
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strings"
+)
+
+// jsonNode is the JSON schema of a node, which is documented by MarshalJSON.
+type jsonNode struct {
+	Type      string      `json:"type"`
+	Tag       string      `json:"tag,omitempty"`
+	Namespace string      `json:"namespace,omitempty"`
+	Attrs     []jsonAttr  `json:"attrs,omitempty"`
+	Text      string      `json:"text,omitempty"`
+	Children  []*jsonNode `json:"children,omitempty"`
+}
+
+type jsonAttr struct {
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+	Val       string `json:"val"`
+}
+
+var jsonNodeTypes = map[html.NodeType]string{
+	html.DocumentNode: "document",
+	html.ElementNode:  "element",
+	html.TextNode:     "text",
+	html.CommentNode:  "comment",
+	html.DoctypeNode:  "doctype",
+	html.RawNode:      "raw",
+}
+
+func toJSONNode(n *Node) (*jsonNode, error) {
+	typ, ok := jsonNodeTypes[n.Type]
+	if !ok {
+		return nil, fmt.Errorf("not allow to marshal node type %d", n.Type)
+	}
+	jn := &jsonNode{Type: typ, Namespace: n.Namespace}
+	switch n.Type {
+	case html.ElementNode, html.DoctypeNode:
+		jn.Tag = n.Data
+	default:
+		jn.Text = n.Data
+	}
+	for _, attr := range n.Attr {
+		jn.Attrs = append(jn.Attrs, jsonAttr{Namespace: attr.Namespace, Key: attr.Key, Val: attr.Val})
+	}
+	for c := range n.Children() {
+		jc, err := toJSONNode(c)
+		if err != nil {
+			return nil, err
+		}
+		jn.Children = append(jn.Children, jc)
+	}
+	return jn, nil
+}
+
+func (jn *jsonNode) build(n *Node) error {
+	n.Namespace = jn.Namespace
+	switch jn.Type {
+	case "document":
+		n.Type = html.DocumentNode
+	case "element":
+		n.Type, n.Data, n.DataAtom = html.ElementNode, jn.Tag, atom.Lookup([]byte(jn.Tag))
+	case "doctype":
+		n.Type, n.Data = html.DoctypeNode, jn.Tag
+	case "text":
+		n.Type, n.Data = html.TextNode, jn.Text
+	case "comment":
+		n.Type, n.Data = html.CommentNode, jn.Text
+	case "raw":
+		n.Type, n.Data = html.RawNode, jn.Text
+	default:
+		return fmt.Errorf("unknown node type `%s`", jn.Type)
+	}
+	for _, attr := range jn.Attrs {
+		n.Attr = append(n.Attr, html.Attribute{Namespace: attr.Namespace, Key: attr.Key, Val: attr.Val})
+	}
+	for _, jc := range jn.Children {
+		c := &Node{}
+		if err := jc.build(c); err != nil {
+			return err
+		}
+		n.AppendChild(c)
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON of the node tree rooted at this node, whose schema is:
+//
+//	{
+//	  "type": "document" | "element" | "text" | "comment" | "doctype" | "raw",
+//	  "tag": "a",                   // the tag of element, or the name of doctype
+//	  "namespace": "svg",           // the namespace of element, omitted for HTML
+//	  "attrs": [{"namespace": "xlink", "key": "href", "val": "#x"}],
+//	  "text": "...",                // the data of text, comment and raw
+//	  "children": [...]             // the child nodes in order
+//	}
+//
+// The empty fields are omitted. The node tree unmarshalled from the JSON renders the same HTML as this node.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	if n == nil {
+		return []byte("null"), nil
+	}
+	jn, err := toJSONNode(n)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jn)
+}
+
+// UnmarshalJSON replaces this node with the node tree of the JSON marshalled by MarshalJSON,
+// keeping its parent and siblings. The original children are detached, and the positions recorded for this node are dropped.
+// JSON null leaves this node unchanged.
+func (n *Node) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var jn jsonNode
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return err
+	}
+	built := &Node{}
+	if err := jn.build(built); err != nil {
+		return err
+	}
+	forgetNode(n)
+	parent, prev, next := n.Parent, n.PrevSibling, n.NextSibling
+	n.Empty()
+	*n = *built
+	n.Parent, n.PrevSibling, n.NextSibling = parent, prev, next
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		c.Parent = (*html.Node)(n)
+	}
+	return nil
+}
+
+// ToMap returns the simplified map of the node tree rooted at this node, which is suitable for querying by tools like jq.
+//
+// An element node is {"tag": tag, "namespace": namespace, "attrs": {key: val}, "children": [...]},
+// where the children are the maps of the child element nodes and the strings of the child text nodes,
+// and the blank text nodes, the comments and the doctype are dropped. A document node has only "children",
+// and a text node is {"text": text}. The empty fields are omitted.
+func (n *Node) ToMap() map[string]any {
+	if n == nil {
+		return nil
+	}
+	m := map[string]any{}
+	switch n.Type {
+	case html.TextNode, html.CommentNode, html.RawNode:
+		m["text"] = n.Data
+		return m
+	case html.ElementNode:
+		m["tag"] = n.Data
+		if n.Namespace != "" {
+			m["namespace"] = n.Namespace
+		}
+		if len(n.Attr) > 0 {
+			attrs := map[string]string{}
+			for _, attr := range n.Attr {
+				key := attr.Key
+				if attr.Namespace != "" {
+					key = attr.Namespace + ":" + key
+				}
+				attrs[key] = attr.Val
+			}
+			m["attrs"] = attrs
+		}
+	}
+	var children []any
+	for c := range n.Children() {
+		switch c.Type {
+		case html.TextNode:
+			if strings.TrimSpace(c.Data) != "" {
+				children = append(children, c.Data)
+			}
+		case html.ElementNode:
+			children = append(children, c.ToMap())
+		}
+	}
+	if len(children) > 0 {
+		m["children"] = children
+	}
+	return m
+}
@@ -0,0 +1,127 @@
+package supersimplesoup
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	tests := []string{
+		testHTML,
+		testRenderHTML,
+		`<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd"><p>a &amp; b<!-- c --></p>`,
+		`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#x"></use><foreignObject><b>x</b></foreignObject></svg><math><mi>x</mi></math>`,
+		`<table><tr><td>1</td></tr></table><template><p>t</p></template><textarea>
+x</textarea>`,
+	}
+	for _, test := range tests {
+		doc, err := Parse(strings.NewReader(test))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Node
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.HTML() != doc.HTML() {
+			t.Errorf("unmarshalled html, want\n%s\ngot\n%s", doc.HTML(), got.HTML())
+		}
+		if a := got.Query("a"); a != nil && (a.ParentNode() == nil || a.DataAtom == 0) {
+			t.Errorf("unmarshalled element node, got %+v", a)
+		}
+	}
+
+	n := parseBody(t, `<a href="/x" class="c">link<!--note--></a>`).Query("a")
+	data, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"element","tag":"a","attrs":[{"key":"href","val":"/x"},{"key":"class","val":"c"}],"children":[{"type":"text","text":"link"},{"type":"comment","text":"note"}]}`
+	if string(data) != want {
+		t.Errorf("json, want %s, got %s", want, data)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	doc := parseBody(t, `<p id="p1">old</p><p id="p2">next</p>`)
+	p := doc.Query("p", "id", "p1")
+	if err := json.Unmarshal([]byte(`{"type":"element","tag":"div","children":[{"type":"text","text":"new"}]}`), p); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.HTML(), `<body><div>new</div><p id="p2">next</p></body>`; got != want {
+		t.Errorf("html after unmarshal, want %s, got %s", want, got)
+	}
+	if p.FirstChildNode().ParentNode() != p || p.NextSiblingNode().PrevSiblingNode() != p {
+		t.Errorf("unmarshalled node links broken")
+	}
+	if err := json.Unmarshal([]byte(`{"type":"element","tag":"div","children":[{"type":"bogus"}]}`), p); err == nil || p.Data != "div" {
+		t.Errorf("unmarshal unknown node type, want error and node unchanged, got %v and %s", err, p.Data)
+	}
+	if err := json.Unmarshal([]byte(`null`), p); err != nil || p.Data != "div" {
+		t.Errorf("unmarshal null, want node unchanged, got %v and %s", err, p.Data)
+	}
+}
+
+func TestUnmarshalJSONPosition(t *testing.T) {
+	doc, _, err := ParseWithOptions(context.Background(), strings.NewReader(`<p id="p1">old</p><p id="p2">next</p>`), ParseOptions{RecordPositions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, p2 := doc.Query("p", "id", "p1"), doc.Query("p", "id", "p2")
+	if !p1.Position().IsValid() || !p2.Position().IsValid() {
+		t.Fatalf("positions, got %s and %s", p1.Position(), p2.Position())
+	}
+	if err := json.Unmarshal([]byte(`{"type":"element","tag":"div"}`), p1); err != nil {
+		t.Fatal(err)
+	}
+	if p1.Position().IsValid() || p1.EndPosition().IsValid() || !p2.Position().IsValid() {
+		t.Errorf("positions after unmarshal, want - and valid, got %s and %s", p1.Position(), p2.Position())
+	}
+}
+
+func TestMarshalJSONResults(t *testing.T) {
+	doc := parseBody(t, `<link rel="canonical" href="/c"><meta property="og:title" content="T">`+
+		`<script type="application/ld+json">{"a": 1}</script><div itemscope><span itemprop="name">x</span></div><a href="/x">x</a>`)
+	for _, v := range []any{doc.Links(nil), Metadata(doc, nil)} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), `"type":"element"`) || strings.Contains(string(data), `"Node"`) {
+			t.Errorf("json, want no nodes, got %s", data)
+		}
+	}
+}
+
+func TestToMap(t *testing.T) {
+	n := parseBody(t, `<div>
+		<a href="/x">link</a> text <!-- c -->
+		<svg><use xlink:href="#y"></use></svg>
+	</div>`).Query("div")
+	want := map[string]any{
+		"tag": "div",
+		"children": []any{
+			map[string]any{"tag": "a", "attrs": map[string]string{"href": "/x"}, "children": []any{"link"}},
+			" text ",
+			map[string]any{"tag": "svg", "namespace": "svg", "children": []any{
+				map[string]any{"tag": "use", "namespace": "svg", "attrs": map[string]string{"xlink:href": "#y"}},
+			}},
+		},
+	}
+	if got := n.ToMap(); !reflect.DeepEqual(want, got) {
+		t.Errorf("map, want %v, got %v", want, got)
+	}
+	if got, want := n.Query("a").FirstChildNode().ToMap(), map[string]any{"text": "link"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("text node map, want %v, got %v", want, got)
+	}
+	if _, err := json.Marshal(n.ToMap()); err != nil {
+		t.Errorf("marshal map, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package supersimplesoup
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Link is a URL referenced by an element node.
+type Link struct {
+	URL  string   // the normalized absolute URL
+	Text string   // the anchor text of a, the alt of area and img, or the title of iframe
+	Rel  []string // the rel values of a, area and link
+	Attr string   // the attribute which the URL comes from, e.g. href, src or srcset
+	Node *Node    `json:"-"` // the element node which references the URL
+}
+
+// AbsURL returns the normalized absolute URL of the key specified attribute of this node,
+// which is resolved against the href of the base element node of the document and then the base URL.
+//
+// The base may be nil if the document has an absolute base element node.
+// It returns empty string if the attribute is not found or is not a valid URL.
+func (n *Node) AbsURL(attr string, base *url.URL) string {
+	if n == nil {
+		return ""
+	}
+	return absURL(n, attr, documentBase(n, base))
+}
+
+// absURL is like AbsURL, but the base is already resolved by documentBase,
+// so the callers resolving many URLs of a document look for the base element node only once.
+func absURL(n *Node, attr string, base *url.URL) string {
+	ref, ok := lookupAttr(n, attr)
+	if !ok {
+		return ""
+	}
+	u, err := resolveRef(base, ref)
+	if err != nil {
+		return ""
+	}
+	return normalizeURL(u)
+}
+
+// Links returns the links of the element nodes under this node, including this node, in tree order.
+//
+// They are the href of a, area and link, the src and srcset of img, the src of script and iframe,
+// the action of form and the URL of meta refresh, resolved as AbsURL.
+// The invalid URLs and the javascript and data URLs are skipped.
+func (n *Node) Links(base *url.URL) (links []Link) {
+	if n == nil {
+		return nil
+	}
+	base = documentBase(n, base)
+	add := func(e *Node, attr, ref, text string) {
+		ref = cleanURL(ref)
+		r, err := url.Parse(ref)
+		if err != nil || r.Scheme == "javascript" || r.Scheme == "data" {
+			return
+		}
+		u := r
+		if base != nil {
+			u = base.ResolveReference(r)
+		}
+		link := Link{URL: normalizeURL(u), Text: text, Attr: attr, Node: e}
+		switch e.Data {
+		case "a", "area", "link":
+			if rel := strings.Fields(strings.ToLower(e.Attribute("rel"))); len(rel) > 0 {
+				link.Rel = rel
+			}
+		}
+		links = append(links, link)
+	}
+	n.Walk(func(e *Node) error {
+		if !e.IsElementNode() {
+			return nil
+		}
+		switch e.Data {
+		case "a":
+			if href, ok := lookupAttr(e, "href"); ok {
+				add(e, "href", href, collapseSpace(e.FullText()))
+			}
+		case "area":
+			if href, ok := lookupAttr(e, "href"); ok {
+				add(e, "href", href, e.Attribute("alt"))
+			}
+		case "link":
+			if href, ok := lookupAttr(e, "href"); ok {
+				add(e, "href", href, "")
+			}
+		case "img":
+			alt := e.Attribute("alt")
+			if src, ok := lookupAttr(e, "src"); ok {
+				add(e, "src", src, alt)
+			}
+			for _, src := range parseSrcset(e.Attribute("srcset")) {
+				add(e, "srcset", src, alt)
+			}
+		case "script":
+			if src, ok := lookupAttr(e, "src"); ok {
+				add(e, "src", src, "")
+			}
+		case "iframe":
+			if src, ok := lookupAttr(e, "src"); ok {
+				add(e, "src", src, e.Attribute("title"))
+			}
+		case "form":
+			if action, ok := lookupAttr(e, "action"); ok {
+				add(e, "action", action, "")
+			}
+		case "meta":
+			if strings.EqualFold(e.Attribute("http-equiv"), "refresh") {
+				if ref, ok := parseRefresh(e.Attribute("content")); ok {
+					add(e, "content", ref, "")
+				}
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// documentBase returns the base URL resolved against the href of the first base element node of the document of the node.
+func documentBase(n *Node, base *url.URL) *url.URL {
+	for e := range rootNode(n).Elements() {
+		if e.Data != "base" {
+			continue
+		}
+		href, ok := lookupAttr(e, "href")
+		if !ok {
+			continue
+		}
+		if b, err := url.Parse(cleanURL(href)); err == nil {
+			if base == nil {
+				return b
+			}
+			return base.ResolveReference(b)
+		}
+		break
+	}
+	return base
+}
+
+// resolveURL resolves the reference against the base URL of the document of the node.
+func resolveURL(n *Node, base *url.URL, ref string) (*url.URL, error) {
+	return resolveRef(documentBase(n, base), ref)
+}
+
+// resolveRef resolves the reference against the base URL resolved by documentBase, which may be nil.
+func resolveRef(base *url.URL, ref string) (*url.URL, error) {
+	r, err := url.Parse(cleanURL(ref))
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return r, nil
+	}
+	return base.ResolveReference(r), nil
+}
+
+// cleanURL removes the leading and trailing white space and the tab and newline in the URL, as the URL parser of browsers.
+func cleanURL(s string) string {
+	return strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(strings.TrimSpace(s))
+}
+
+// normalizeURL returns the URL with the lower case host, without the default port and the empty query,
+// and with the root path for the empty path of http and https URLs.
+func normalizeURL(u *url.URL) string {
+	v := *u
+	v.Host = strings.ToLower(v.Host)
+	switch v.Scheme {
+	case "http":
+		v.Host = strings.TrimSuffix(v.Host, ":80")
+	case "https":
+		v.Host = strings.TrimSuffix(v.Host, ":443")
+	}
+	if (v.Scheme == "http" || v.Scheme == "https") && v.Host != "" && v.Path == "" && v.Opaque == "" {
+		v.Path = "/"
+	}
+	v.ForceQuery = false
+	return v.String()
+}
+
+// parseSrcset returns the URLs of the image candidate strings of the srcset attribute.
+func parseSrcset(srcset string) (urls []string) {
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return
+		}
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end < 0 {
+			end = len(s)
+		}
+		u := s[:end]
+		s = s[end:]
+		if trimmed := strings.TrimRight(u, ","); trimmed != u {
+			// The URL ending with commas has no descriptors.
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, u)
+		// Skip the descriptors, where the commas in the parentheses don't end the candidate.
+		depth := 0
+		i := 0
+		for ; i < len(s); i++ {
+			if s[i] == '(' {
+				depth++
+			} else if s[i] == ')' && depth > 0 {
+				depth--
+			} else if s[i] == ',' && depth == 0 {
+				break
+			}
+		}
+		s = s[i:]
+	}
+}
+
+var refreshRegexp = regexp.MustCompile(`^\s*[\d.]*\s*(?:[;,]\s*(?:(?i:url)\s*=\s*)?(.*))?$`)
+
+// parseRefresh returns the URL of the content attribute of meta refresh, e.g. "5; url=/next".
+func parseRefresh(content string) (string, bool) {
+	m := refreshRegexp.FindStringSubmatch(content)
+	if m == nil || strings.TrimSpace(m[1]) == "" {
+		return "", false
+	}
+	ref := strings.TrimSpace(m[1])
+	if len(ref) > 0 && (ref[0] == '"' || ref[0] == '\'') {
+		if i := strings.IndexByte(ref[1:], ref[0]); i >= 0 {
+			ref = ref[1 : i+1]
+		} else {
+			ref = ref[1:]
+		}
+	}
+	return ref, ref != ""
+}
@@ -0,0 +1,107 @@
+package supersimplesoup
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+const testLinkHTML = `<head>
+	<base href="/docs/guide/">
+	<link rel="Stylesheet Preload" href="../css/site.css">
+	<meta http-equiv="Refresh" content="5; URL='next.html?'">
+	<script src="//CDN.example.com:443/app.js"></script>
+</head>
+<body>
+	<a href="../a.html" rel="nofollow"> About
+		us </a>
+	<a href="#top">Top</a>
+	<a href="javascript:void(0)">JS</a>
+	<a name="anchor">No href</a>
+	<img src="img/logo.png" srcset="img/logo-2x.png 2x, img/logo,3x.png 3x, img/w.png 100w" alt="Logo">
+	<iframe src="HTTP://Example.com:80" title="Frame"></iframe>
+	<form action="search"></form>
+	<map><area href="/map" alt="Map"></map>
+</body>`
+
+func TestLinks(t *testing.T) {
+	doc := parseBody(t, testLinkHTML)
+	for doc.ParentNode() != nil {
+		doc = doc.ParentNode()
+	}
+	base, _ := url.Parse("https://example.com/index.html")
+	tests := []struct {
+		url  string
+		text string
+		rel  []string
+		attr string
+		tag  string
+	}{
+		{"https://example.com/docs/css/site.css", "", []string{"stylesheet", "preload"}, "href", "link"},
+		{"https://example.com/docs/guide/next.html", "", nil, "content", "meta"},
+		{"https://cdn.example.com/app.js", "", nil, "src", "script"},
+		{"https://example.com/docs/a.html", "About us", []string{"nofollow"}, "href", "a"},
+		{"https://example.com/docs/guide/#top", "Top", nil, "href", "a"},
+		{"https://example.com/docs/guide/img/logo.png", "Logo", nil, "src", "img"},
+		{"https://example.com/docs/guide/img/logo-2x.png", "Logo", nil, "srcset", "img"},
+		{"https://example.com/docs/guide/img/logo,3x.png", "Logo", nil, "srcset", "img"},
+		{"https://example.com/docs/guide/img/w.png", "Logo", nil, "srcset", "img"},
+		{"http://example.com/", "Frame", nil, "src", "iframe"},
+		{"https://example.com/docs/guide/search", "", nil, "action", "form"},
+		{"https://example.com/map", "Map", nil, "href", "area"},
+	}
+	links := doc.Links(base)
+	if len(links) != len(tests) {
+		t.Fatalf("links count, want %d, got %d: %+v", len(tests), len(links), links)
+	}
+	for i, test := range tests {
+		link := links[i]
+		if link.URL != test.url || link.Text != test.text || !reflect.DeepEqual(link.Rel, test.rel) || link.Attr != test.attr || link.Node.Data != test.tag {
+			t.Errorf("link %d, want %+v, got %+v", i, test, link)
+		}
+	}
+}
+
+func TestAbsURL(t *testing.T) {
+	n := parseBody(t, `<a href=" ../a.html?x=1#f ">a</a><img src="b.png">`)
+	base, _ := url.Parse("http://example.com:8080/p/q/r.html")
+	tests := []struct {
+		tag  string
+		attr string
+		base *url.URL
+		want string
+	}{
+		{"a", "href", base, "http://example.com:8080/p/a.html?x=1#f"},
+		{"img", "src", base, "http://example.com:8080/p/q/b.png"},
+		{"img", "title", base, ""},
+		{"img", "src", nil, "b.png"},
+	}
+	for _, test := range tests {
+		if got := n.Query(test.tag).AbsURL(test.attr, test.base); got != test.want {
+			t.Errorf("`%s` %s url, want %q, got %q", test.tag, test.attr, test.want, got)
+		}
+	}
+	doc := parseBody(t, `<base href="https://example.org/x/"><a href="y">y</a>`)
+	if got, want := doc.Query("a").AbsURL("href", nil), "https://example.org/x/y"; got != want {
+		t.Errorf("url with absolute base element, want %q, got %q", want, got)
+	}
+}
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		urls   []string
+	}{
+		{"a.png", []string{"a.png"}},
+		{" a.png 1x,b.png  2x ", []string{"a.png", "b.png"}},
+		{"a.png, b.png, c.png,", []string{"a.png", "b.png", "c.png"}},
+		{"a,b.png 1x", []string{"a,b.png"}},
+		{"a.png (x, y) 1x, b.png", []string{"a.png", "b.png"}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		if got := parseSrcset(test.srcset); !reflect.DeepEqual(test.urls, got) {
+			t.Errorf("`%s` srcset urls, want %q, got %q", test.srcset, test.urls, got)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package supersimplesoup
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownRule converts the element node to Markdown, where content is the converted Markdown of its children.
+//
+// The block element nodes should be converted to the Markdown surrounded by blank lines.
+type MarkdownRule func(node *Node, content string) string
+
+// MarkdownOptions controls how Markdown converts the nodes.
+type MarkdownOptions struct {
+	// Base is the URL which the links and images are resolved against as AbsURL. Nil leaves them as they are.
+	Base *url.URL
+	// BulletMarker is the marker of the unordered list items, "-" by default.
+	BulletMarker string
+	// EmphasisDelimiter is the delimiter of em and i element nodes, "*" by default.
+	EmphasisDelimiter string
+	// StrongDelimiter is the delimiter of strong and b element nodes, "**" by default.
+	StrongDelimiter string
+	// Rules is the rules of the tags, which override the default conversions, e.g. for the custom element nodes.
+	Rules map[string]MarkdownRule
+}
+
+// Markdown returns the CommonMark of this node with the GitHub Flavored Markdown tables and strikethrough.
+//
+// The element nodes not rendered, e.g. script, style and head, are skipped as InnerText,
+// the unknown element nodes are converted as their children, and no HTML is kept in the Markdown,
+// except <br> for the line breaks in the table cells, which GFM tables cannot write otherwise.
+func (n *Node) Markdown(opts MarkdownOptions) string {
+	if n == nil {
+		return ""
+	}
+	if opts.BulletMarker == "" {
+		opts.BulletMarker = "-"
+	}
+	if opts.EmphasisDelimiter == "" {
+		opts.EmphasisDelimiter = "*"
+	}
+	if opts.StrongDelimiter == "" {
+		opts.StrongDelimiter = "**"
+	}
+	c := &markdownConverter{opts: opts}
+	if opts.Base != nil {
+		c.base = documentBase(n, opts.Base)
+	}
+	return strings.Trim(unmarkMarkdown(c.node(n)), " \n")
+}
+
+type markdownConverter struct {
+	opts MarkdownOptions
+	base *url.URL // the Base resolved against the base element node of the document
+}
+
+// markdownTextMark marks the start of the escaped text in the converted Markdown until unmarkMarkdown removes it.
+// The parser drops the NUL chars in the text, and escapeMarkdown removes the others.
+const markdownTextMark = "\x00"
+
+var (
+	markdownEscaper = strings.NewReplacer(markdownTextMark, "", `\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+		"<", `\<`, "&", `\&`)
+	markdownLineRegexp   = regexp.MustCompile(`^ {0,3}([-+#>]|\d+[.)]|=+ *$)`)
+	markdownPrefixRegexp = regexp.MustCompile(`^(?:[ >]|(?:[-+*]|\d+[.)]) )*$`)
+)
+
+// escapeMarkdown escapes the chars which would be parsed as Markdown in the text, and marks the start of the text,
+// where unmarkMarkdown escapes the block marker if the text starts a line.
+func escapeMarkdown(s string) string {
+	s = markdownEscaper.Replace(s)
+	i := len(s) - len(strings.TrimLeft(s, " "))
+	return s[:i] + markdownTextMark + s[i:]
+}
+
+// unmarkMarkdown removes the text marks, escaping the block markers, e.g. "-", "1." and the setext underline "===", which start the lines with the text
+// rather than the Markdown written by the conversions, where the lines may be in the block quotes and the list items.
+func unmarkMarkdown(s string) string {
+	if !strings.Contains(s, markdownTextMark) {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		j := strings.Index(line, markdownTextMark)
+		if j < 0 {
+			continue
+		}
+		prefix, text := line[:j], strings.ReplaceAll(line[j:], markdownTextMark, "")
+		if markdownPrefixRegexp.MatchString(prefix) {
+			if m := markdownLineRegexp.FindStringSubmatchIndex(text); m != nil {
+				// The digits cannot be escaped, so the list marker is escaped at the delimiter after them.
+				i := m[2]
+				if text[i] >= '0' && text[i] <= '9' {
+					i = m[3] - 1
+				}
+				text = text[:i] + `\` + text[i:]
+			}
+		}
+		lines[i] = prefix + text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// joinMarkdown appends the Markdown piece, collapsing the spaces and the blank lines at the boundary.
+func joinMarkdown(acc, piece string) string {
+	if strings.HasPrefix(piece, "\n") {
+		t := len(acc) - len(strings.TrimRight(acc, "\n"))
+		l := len(piece) - len(strings.TrimLeft(piece, "\n"))
+		acc = strings.TrimRight(strings.TrimRight(acc, "\n"), " ")
+		piece = strings.TrimLeft(piece, "\n")
+		if acc != "" {
+			acc += strings.Repeat("\n", min(max(t, l), 2))
+		}
+	}
+	if strings.HasPrefix(piece, " ") && (acc == "" || strings.HasSuffix(acc, " ") || strings.HasSuffix(acc, "\n")) {
+		piece = strings.TrimLeft(piece, " ")
+	}
+	return acc + piece
+}
+
+func (c *markdownConverter) children(n *Node) string {
+	var acc string
+	for child := range n.Children() {
+		acc = joinMarkdown(acc, c.node(child))
+	}
+	return acc
+}
+
+func (c *markdownConverter) node(n *Node) string {
+	if n.IsTextNode() {
+		s := n.Data
+		if strings.TrimSpace(s) == "" {
+			if s == "" {
+				return ""
+			}
+			return " "
+		}
+		return escapeMarkdown(collapseSpaceKeepEdges(s))
+	}
+	if !n.IsElementNode() {
+		return c.children(n)
+	}
+	if rule, ok := c.opts.Rules[n.Data]; ok {
+		return rule(n, unmarkMarkdown(c.children(n)))
+	}
+	if isHiddenElement(n) {
+		return ""
+	}
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.Data[1:])
+		content := strings.ReplaceAll(strings.TrimSpace(c.children(n)), "\n", " ")
+		return "\n\n" + strings.Repeat("#", level) + " " + content + "\n\n"
+	case "p":
+		return "\n\n" + strings.Trim(c.children(n), " \n") + "\n\n"
+	case "br":
+		return "  \n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "em", "i":
+		return wrapInline(c.children(n), c.opts.EmphasisDelimiter)
+	case "strong", "b":
+		return wrapInline(c.children(n), c.opts.StrongDelimiter)
+	case "del", "s", "strike":
+		return wrapInline(c.children(n), "~~")
+	case "code", "kbd", "samp", "tt":
+		return inlineCode(n.FullText())
+	case "a":
+		return c.link(n)
+	case "img":
+		return c.image(n)
+	case "pre":
+		return c.codeBlock(n)
+	case "blockquote":
+		content := strings.Trim(c.children(n), " \n")
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+	case "ul", "ol":
+		return c.list(n)
+	case "li":
+		// The li element node out of a list.
+		return c.listItem(n, c.opts.BulletMarker+" ")
+	case "table":
+		return c.table(n)
+	}
+	content := c.children(n)
+	if displayBlockTags[n.Data] {
+		return "\n\n" + strings.Trim(content, " \n") + "\n\n"
+	}
+	return content
+}
+
+// wrapInline wraps the content with the delimiter, keeping the spaces at the edges out of the delimiters.
+func wrapInline(content, delimiter string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return content
+	}
+	start := content[:strings.Index(content, trimmed)]
+	end := content[len(start)+len(trimmed):]
+	return start + delimiter + trimmed + delimiter + end
+}
+
+var backtickRegexp = regexp.MustCompile("`+")
+
+// inlineCode returns the code span with the backtick string longer than the ones in the code.
+func inlineCode(code string) string {
+	code = strings.ReplaceAll(code, "\n", " ")
+	if code == "" {
+		return ""
+	}
+	fence := "`"
+	for _, ticks := range backtickRegexp.FindAllString(code, -1) {
+		if len(ticks) >= len(fence) {
+			fence = strings.Repeat("`", len(ticks)+1)
+		}
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+func (c *markdownConverter) url(n *Node, attr string) string {
+	if c.base != nil {
+		if u := absURL(n, attr, c.base); u != "" {
+			return markdownURL(u)
+		}
+	}
+	return markdownURL(cleanURL(n.Attribute(attr)))
+}
+
+// markdownURL returns the link destination, which is enclosed in the angle brackets if it contains spaces or parentheses.
+func markdownURL(u string) string {
+	if strings.ContainsAny(u, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(u) + ">"
+	}
+	return u
+}
+
+func markdownTitle(n *Node) string {
+	title := strings.TrimSpace(n.Attribute("title"))
+	if title == "" {
+		return ""
+	}
+	return ` "` + strings.ReplaceAll(strings.ReplaceAll(title, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+func (c *markdownConverter) link(n *Node) string {
+	content := c.children(n)
+	if _, ok := lookupAttr(n, "href"); !ok {
+		return content
+	}
+	trimmed := strings.TrimSpace(strings.ReplaceAll(content, "\n", " "))
+	if trimmed == "" {
+		return content
+	}
+	start := content[:len(content)-len(strings.TrimLeft(content, " \n"))]
+	end := content[len(strings.TrimRight(content, " \n")):]
+	return start + "[" + trimmed + "](" + c.url(n, "href") + markdownTitle(n) + ")" + end
+}
+
+func (c *markdownConverter) image(n *Node) string {
+	if _, ok := lookupAttr(n, "src"); !ok {
+		return ""
+	}
+	alt := escapeMarkdown(collapseSpace(n.Attribute("alt")))
+	return "![" + alt + "](" + c.url(n, "src") + markdownTitle(n) + ")"
+}
+
+var languageRegexp = regexp.MustCompile(`(?:^|\s)(?:language|lang)-(\S+)`)
+
+func (c *markdownConverter) codeBlock(n *Node) string {
+	language := ""
+	for _, e := range []*Node{n, n.Query("code")} {
+		if e == nil {
+			continue
+		}
+		if m := languageRegexp.FindStringSubmatch(e.Class()); m != nil {
+			language = m[1]
+			break
+		}
+	}
+	code := strings.TrimSuffix(n.FullText(), "\n")
+	fence := "```"
+	for _, ticks := range backtickRegexp.FindAllString(code, -1) {
+		if len(ticks) >= len(fence) {
+			fence = strings.Repeat("`", len(ticks)+1)
+		}
+	}
+	return "\n\n" + fence + language + "\n" + code + "\n" + fence + "\n\n"
+}
+
+func (c *markdownConverter) list(n *Node) string {
+	ordered := n.Data == "ol"
+	number := 1
+	if start, err := strconv.Atoi(strings.TrimSpace(n.Attribute("start"))); ordered && err == nil {
+		number = start
+	}
+	var items []string
+	for child := range n.Children() {
+		if !child.IsElementNode() {
+			continue
+		}
+		if child.Data != "li" {
+			// The nested list directly in the list.
+			if item := strings.Trim(c.node(child), " \n"); item != "" {
+				items = append(items, indentLines(item, "  "))
+			}
+			continue
+		}
+		marker := c.opts.BulletMarker + " "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		items = append(items, c.listItem(child, marker))
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(items, "\n") + "\n\n"
+}
+
+// listItem returns the list item, where the continuation lines are indented by the width of the marker.
+func (c *markdownConverter) listItem(n *Node, marker string) string {
+	content := strings.Trim(c.children(n), " \n")
+	loose := false
+	for child := range n.Children() {
+		if child.IsElementNode() && (child.Data == "p" || child.Data == "div" || child.Data == "pre" || child.Data == "blockquote") {
+			loose = true
+		}
+	}
+	if !loose {
+		content = blankLinesRegexp.ReplaceAllString(content, "\n")
+	}
+	return marker + indentLines(content, strings.Repeat(" ", len(marker)))
+}
+
+var blankLinesRegexp = regexp.MustCompile(`\n\n+`)
+
+// indentLines indents the lines except the first one and the blank ones.
+func indentLines(s, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *markdownConverter) table(n *Node) string {
+	t, err := n.Table()
+	if err != nil || len(t.cells) == 0 && len(t.Header) == 0 {
+		return c.children(n)
+	}
+	width := len(t.Header)
+	if len(t.cells) > 0 {
+		width = len(t.cells[0])
+	}
+	if width == 0 {
+		return ""
+	}
+	row := func(cells []string) string {
+		return "| " + strings.Join(cells, " | ") + " |"
+	}
+	cell := func(node *Node) string {
+		if node == nil {
+			return ""
+		}
+		s := strings.TrimSpace(c.children(node))
+		s = blankLinesRegexp.ReplaceAllString(s, "<br>")
+		s = strings.ReplaceAll(strings.ReplaceAll(s, "  \n", "<br>"), "\n", " ")
+		return strings.ReplaceAll(s, "|", `\|`)
+	}
+	var lines []string
+	body := t.cells
+	header := make([]string, width)
+	if len(t.Header) > 0 {
+		for i := range header {
+			if i < len(t.Header) {
+				header[i] = strings.ReplaceAll(escapeMarkdown(t.Header[i]), "|", `\|`)
+			}
+		}
+	} else {
+		// GFM tables require a header row, which is the first row.
+		for i := range header {
+			header[i] = cell(body[0][i])
+		}
+		body = body[1:]
+	}
+	lines = append(lines, row(header))
+	delimiter := make([]string, width)
+	for i := range delimiter {
+		delimiter[i] = "---"
+	}
+	lines = append(lines, row(delimiter))
+	for _, cells := range body {
+		texts := make([]string, width)
+		for i := range texts {
+			texts[i] = cell(cells[i])
+		}
+		lines = append(lines, row(texts))
+	}
+	out := "\n\n" + strings.Join(lines, "\n") + "\n\n"
+	if t.Caption != "" {
+		out = "\n\n" + escapeMarkdown(t.Caption) + out
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package supersimplesoup
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{"<h1>Title</h1><h3> Sub  <em>title</em> </h3>", "# Title\n\n### Sub *title*"},
+		{"<p>Hello <b>bold </b>and <i>italic</i>, <del>gone</del>.</p><p>Next</p>", "Hello **bold** and *italic*, ~~gone~~.\n\nNext"},
+		{"<p>1. not a list * or _emphasis_ [x]</p>", "1\\. not a list \\* or \\_emphasis\\_ \\[x\\]"},
+		{"<p>&lt;d&gt; &amp;copy; &lt;!-- x --&gt;</p>", "\\<d> \\&copy; \\<!-- x -->"},
+		{"<p><span>1</span><span>. one</span></p><p>a <span>- b</span> + c</p>", "1\\. one\n\na - b + c"},
+		{"<p>a<br>- b<br><b>#</b> c</p>", "a  \n\\- b  \n**#** c"},
+		{"<p>a<br>===</p><p>b<br>---</p><p>c<br>=x</p>", "a  \n\\===\n\nb  \n\\---\n\nc  \n=x"},
+		{"<table><tr><th>h</th></tr><tr><td>a<br>b</td></tr></table>", "| h |\n| --- |\n| a<br>b |"},
+		{"<ul><li>- x</li><li><p>y</p><p>+ z</p></li></ul><blockquote>&gt; q</blockquote>", "- \\- x\n- y\n\n  \\+ z\n\n> \\> q"},
+		{`<p>See <a href="/docs" title="The &quot;docs&quot;"> the docs </a>!</p>`, `See [the docs](/docs "The \"docs\"") !`},
+		{`<a href="a b.html">x</a><a name="n">anchor</a>`, "[x](<a b.html>)anchor"},
+		{`<img src="/i.png" alt="An *image*">`, `![An \*image\*](/i.png)`},
+		{"line1<br>line2", "line1  \nline2"},
+		{"<p>a</p><hr><p>b</p>", "a\n\n---\n\nb"},
+		{"use <code>fmt.Println</code> or <code>a`b</code>", "use `fmt.Println` or ``a`b``"},
+		{"<pre><code class=\"hljs language-go\">func main() {\n\n\tfmt.Println(\"*\")\n}\n</code></pre>", "```go\nfunc main() {\n\n\tfmt.Println(\"*\")\n}\n```"},
+		{"<pre>```\nx\n```</pre>", "````\n```\nx\n```\n````"},
+		{"<blockquote><p>quoted</p><p>twice</p></blockquote>", "> quoted\n>\n> twice"},
+		{"<ul><li>a</li><li>b<ul><li>c</li><li>d</li></ul></li></ul>", "- a\n- b\n  - c\n  - d"},
+		{`<ol start="9"><li>nine</li><li><p>ten</p><p>more</p></li></ol>`, "9. nine\n10. ten\n\n    more"},
+		{"<div>a<div>b</div></div><section>c</section>", "a\n\nb\n\nc"},
+		{"<script>x()</script><style>p{}</style><span>kept</span>", "kept"},
+		{"<table><thead><tr><th>Name</th><th>Note</th></tr></thead><tbody><tr><td><a href=\"/x\">X</a></td><td>a|b</td></tr><tr><td colspan=\"2\">wide</td></tr></tbody></table>",
+			"| Name | Note |\n| --- | --- |\n| [X](/x) | a\\|b |\n| wide | wide |"},
+		{"<table><tr><td>h1</td><td>h2</td></tr><tr><td>1</td><td>2</td></tr></table>", "| h1 | h2 |\n| --- | --- |\n| 1 | 2 |"},
+	}
+	for _, test := range tests {
+		if got := parseBody(t, test.html).Markdown(MarkdownOptions{}); got != test.want {
+			t.Errorf("`%s` markdown, want %q, got %q", test.html, test.want, got)
+		}
+	}
+}
+
+func TestMarkdownOptions(t *testing.T) {
+	n := parseBody(t, `<ul><li><em>a</em> <strong>b</strong></li></ul><a href="../x">x</a><img src="y.png" alt="y"><x-note kind="tip">Be <b>careful</b></x-note>`)
+	base, _ := url.Parse("https://example.com/docs/page/")
+	got := n.Markdown(MarkdownOptions{
+		Base:              base,
+		BulletMarker:      "*",
+		EmphasisDelimiter: "_",
+		StrongDelimiter:   "__",
+		Rules: map[string]MarkdownRule{
+			"x-note": func(node *Node, content string) string {
+				return "\n\n> **" + strings.ToUpper(node.Attribute("kind")) + "** " + content + "\n\n"
+			},
+		},
+	})
+	want := "* _a_ __b__\n\n[x](https://example.com/docs/x)![y](https://example.com/docs/page/y.png)\n\n> **TIP** Be __careful__"
+	if got != want {
+		t.Errorf("markdown with options, want %q, got %q", want, got)
+	}
+}
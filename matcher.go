@@ -0,0 +1,102 @@
+package supersimplesoup
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatchFunc is the type of the function called by QueryFunc and QueryAllFunc to match each element node.
+type MatchFunc func(node *Node) bool
+
+// Tag returns a MatchFunc that matches the element node with the specified tag.
+func Tag(tag string) MatchFunc {
+	return func(node *Node) bool {
+		return node.IsElementNode() && node.Data == tag
+	}
+}
+
+// HasAttr returns a MatchFunc that matches the node which has the specified attribute.
+func HasAttr(key string) MatchFunc {
+	return func(node *Node) bool {
+		for _, attr := range node.Attr {
+			if attr.Key == key {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AttrEquals returns a MatchFunc that matches the node whose specified attribute is equal to val.
+func AttrEquals(key, val string) MatchFunc {
+	return func(node *Node) bool {
+		for _, attr := range node.Attr {
+			if attr.Key == key {
+				return attr.Val == val
+			}
+		}
+		return false
+	}
+}
+
+// AttrRegexp returns a MatchFunc that matches the node whose specified attribute is matched by re.
+func AttrRegexp(key string, re *regexp.Regexp) MatchFunc {
+	return func(node *Node) bool {
+		for _, attr := range node.Attr {
+			if attr.Key == key {
+				return re.MatchString(attr.Val)
+			}
+		}
+		return false
+	}
+}
+
+// TextContains returns a MatchFunc that matches the node whose full text contains substr.
+func TextContains(substr string) MatchFunc {
+	return func(node *Node) bool {
+		return strings.Contains(node.FullText(), substr)
+	}
+}
+
+// HasChild returns a MatchFunc that matches the node which has a direct child node matched by fn.
+func HasChild(fn MatchFunc) MatchFunc {
+	return func(node *Node) bool {
+		for c := node.FirstChildNode(); c != nil; c = c.NextSiblingNode() {
+			if fn(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// And returns a MatchFunc that matches the node matched by all of fns.
+func And(fns ...MatchFunc) MatchFunc {
+	return func(node *Node) bool {
+		for _, fn := range fns {
+			if !fn(node) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a MatchFunc that matches the node matched by any of fns.
+func Or(fns ...MatchFunc) MatchFunc {
+	return func(node *Node) bool {
+		for _, fn := range fns {
+			if fn(node) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a MatchFunc that matches the node not matched by fn.
+func Not(fn MatchFunc) MatchFunc {
+	return func(node *Node) bool {
+		return !fn(node)
+	}
+}
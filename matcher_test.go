@@ -0,0 +1,58 @@
+package supersimplesoup
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestQueryFunc(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   MatchFunc
+		want []string
+	}{
+		{"tag", Tag("ul"), []string{"ul-id-1", "ul-id-2"}},
+		{"has-attr", And(Tag("a"), HasAttr("href"), AttrEquals("title", "a-title-4")), []string{"a-id-4"}},
+		{"attr-regexp", AttrRegexp("href", regexp.MustCompile(`-[27]$`)), []string{"a-id-2", "a-id-7"}},
+		{"text-contains", And(Tag("a"), TextContains("text-5")), []string{"a-id-5"}},
+		{"or", Or(AttrEquals("id", "li-id-4"), AttrEquals("id", "a-id-1")), []string{"a-id-1", "li-id-4"}},
+		{"not", And(Tag("li"), Not(AttrEquals("class", "li-class-1"))), []string{"li-id-3", "li-id-4"}},
+		{"has-child", HasChild(AttrEquals("id", "a-id-3")), []string{"li-id-2"}},
+		{"none", Tag("table"), nil},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, node := range root.QueryAllFunc(test.fn) {
+			got = append(got, node.ID())
+		}
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("`%s` element attribute id, want %v, got %v", test.name, test.want, got)
+		}
+		node := root.QueryFunc(test.fn)
+		if (node == nil) != (test.want == nil) || node != nil && node.ID() != test.want[0] {
+			t.Errorf("`%s` first element node, want %v, got %v", test.name, test.want, node)
+		}
+	}
+}
+
+func TestQueryFuncChain(t *testing.T) {
+	want := []string{"a-id-2", "a-id-4", "a-id-6", "a-id-8"}
+	var got []string
+	for _, node := range root.QueryAllFunc(Tag("li")).QueryFunc(AttrRegexp("id", regexp.MustCompile(`[02468]$`))) {
+		got = append(got, node.ID())
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("`%s` element attribute id, want %v, got %v", "$$li.even", want, got)
+	}
+
+	want = []string{"a-id-5", "a-id-6", "a-id-7", "a-id-8"}
+	got = nil
+	for _, node := range root.QueryAll("ul").QueryAllFunc(AttrEquals("class", "a-class-2")) {
+		got = append(got, node.ID())
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("`%s` element attribute id, want %v, got %v", "$$ul.$$a-class-2", want, got)
+	}
+}
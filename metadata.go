@@ -0,0 +1,300 @@
+package supersimplesoup
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// Meta is the metadata of a document.
+type Meta struct {
+	Title       string         // the text of the title element
+	Description string         // the content of meta description
+	Canonical   string         // the href of link canonical, resolved as AbsURL
+	Lang        string         // the lang of the html element, or the content of meta content-language
+	OpenGraph   MetaProperties // the OpenGraph properties, e.g. og:title and article:author
+	Twitter     MetaProperties // the Twitter card properties, e.g. twitter:card
+	JSONLD      []JSONLD       // the blocks of script application/ld+json
+	Items       []*Item        // the top level microdata and RDFa items
+}
+
+// MetaProperty is a property of meta element node.
+type MetaProperty struct {
+	Name  string
+	Value string
+	Node  *Node `json:"-"`
+}
+
+// MetaProperties is the properties in tree order.
+type MetaProperties []MetaProperty
+
+// Get returns the first value of the named property, or empty string if none.
+func (ps MetaProperties) Get(name string) string {
+	for _, p := range ps {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// All returns all the values of the named property, e.g. the several og:image.
+func (ps MetaProperties) All(name string) (values []string) {
+	for _, p := range ps {
+		if p.Name == name {
+			values = append(values, p.Value)
+		}
+	}
+	return
+}
+
+// JSONLD is a JSON-LD block.
+type JSONLD struct {
+	Data any   // the decoded JSON value, nil if it fails to decode
+	Err  error // the decoding error
+	Node *Node `json:"-"` // the script element node
+}
+
+// Item is a microdata item of the itemscope element node, or a RDFa item of the typeof element node.
+type Item struct {
+	Type  []string   // the itemtype or the typeof resolved against the vocab, e.g. https://schema.org/Person
+	ID    string     // the itemid or the resource
+	Props []ItemProp // the properties in tree order
+	Node  *Node      `json:"-"`
+}
+
+// ItemProp is a property of item.
+type ItemProp struct {
+	Name  string // the itemprop or the property
+	Value string // the value of the property, or empty string if the value is an item
+	Item  *Item  // the nested item, or nil
+	Node  *Node  `json:"-"`
+}
+
+// Get returns the first value of the named property, or empty string if none.
+func (item *Item) Get(name string) string {
+	for _, p := range item.Props {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// Item returns the first nested item of the named property, or nil if none.
+func (item *Item) Item(name string) *Item {
+	for _, p := range item.Props {
+		if p.Name == name && p.Item != nil {
+			return p.Item
+		}
+	}
+	return nil
+}
+
+var openGraphPrefixes = []string{"og:", "article:", "book:", "profile:", "music:", "video:", "fb:"}
+
+// Metadata returns the metadata of the document which the node belongs to.
+//
+// The canonical URL, the item ids and the URL values of the item properties are resolved as AbsURL with the base,
+// which may be nil if the document has an absolute base element node.
+func Metadata(doc *Node, base *url.URL) *Meta {
+	meta := &Meta{}
+	if doc == nil {
+		return meta
+	}
+	doc = rootNode(doc)
+	base = documentBase(doc, base)
+	for e := range doc.Elements() {
+		switch e.Data {
+		case "html":
+			if meta.Lang == "" {
+				meta.Lang = strings.TrimSpace(e.Attribute("lang"))
+			}
+		case "title":
+			if meta.Title == "" {
+				meta.Title = collapseSpace(e.FullText())
+			}
+		case "link":
+			if meta.Canonical == "" && containsString(strings.Fields(strings.ToLower(e.Attribute("rel"))), "canonical") {
+				meta.Canonical = absURL(e, "href", base)
+			}
+		case "meta":
+			content := strings.TrimSpace(e.Attribute("content"))
+			name := strings.ToLower(strings.TrimSpace(e.Attribute("name")))
+			property := strings.ToLower(strings.TrimSpace(e.Attribute("property")))
+			switch {
+			case name == "description":
+				if meta.Description == "" {
+					meta.Description = content
+				}
+			case strings.EqualFold(e.Attribute("http-equiv"), "content-language"):
+				if meta.Lang == "" {
+					meta.Lang = content
+				}
+			case strings.HasPrefix(name, "twitter:"):
+				meta.Twitter = append(meta.Twitter, MetaProperty{Name: name, Value: content, Node: e})
+			case strings.HasPrefix(property, "twitter:"):
+				meta.Twitter = append(meta.Twitter, MetaProperty{Name: property, Value: content, Node: e})
+			case hasAnyPrefix(property, openGraphPrefixes):
+				meta.OpenGraph = append(meta.OpenGraph, MetaProperty{Name: property, Value: content, Node: e})
+			}
+		case "script":
+			if strings.EqualFold(strings.TrimSpace(e.Attribute("type")), "application/ld+json") {
+				block := JSONLD{Node: e}
+				block.Err = json.Unmarshal([]byte(e.FullText()), &block.Data)
+				meta.JSONLD = append(meta.JSONLD, block)
+			}
+		}
+		if isItemScope(e, microdata) && !hasItemProp(e, microdata) {
+			meta.Items = append(meta.Items, newItem(e, microdata, base, nil))
+		}
+		if isItemScope(e, rdfa) && !hasItemProp(e, rdfa) {
+			meta.Items = append(meta.Items, newItem(e, rdfa, base, nil))
+		}
+	}
+	return meta
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// itemSyntax is the attributes of microdata or RDFa.
+type itemSyntax struct {
+	scope string
+	prop  string
+	rdfa  bool
+}
+
+var (
+	microdata = itemSyntax{scope: "itemscope", prop: "itemprop"}
+	rdfa      = itemSyntax{scope: "typeof", prop: "property", rdfa: true}
+)
+
+func isItemScope(e *Node, syntax itemSyntax) bool {
+	_, ok := lookupAttr(e, syntax.scope)
+	return ok
+}
+
+func hasItemProp(e *Node, syntax itemSyntax) bool {
+	return strings.TrimSpace(e.Attribute(syntax.prop)) != ""
+}
+
+// newItem returns the item of the element node, where seen is the items being built, which breaks the cycles of itemref.
+func newItem(e *Node, syntax itemSyntax, base *url.URL, seen map[*Node]bool) *Item {
+	item := &Item{Node: e}
+	if seen[e] {
+		return item
+	}
+	if seen == nil {
+		seen = map[*Node]bool{}
+	}
+	seen[e] = true
+	defer delete(seen, e)
+	if syntax.rdfa {
+		vocab := ""
+		for p := e; p != nil; p = p.ParentNode() {
+			if v, ok := lookupAttr(p, "vocab"); ok {
+				vocab = strings.TrimSpace(v)
+				break
+			}
+		}
+		for _, typ := range strings.Fields(e.Attribute("typeof")) {
+			if !strings.Contains(typ, ":") {
+				typ = vocab + typ
+			}
+			item.Type = append(item.Type, typ)
+		}
+		item.ID = absURL(e, "resource", base)
+		if item.ID == "" {
+			item.ID = absURL(e, "about", base)
+		}
+	} else {
+		item.Type = strings.Fields(e.Attribute("itemtype"))
+		item.ID = absURL(e, "itemid", base)
+	}
+
+	var collect func(n *Node)
+	collect = func(n *Node) {
+		for c := range n.Children() {
+			if !c.IsElementNode() {
+				continue
+			}
+			if hasItemProp(c, syntax) {
+				item.Props = append(item.Props, newItemProps(c, syntax, base, seen)...)
+			}
+			if !isItemScope(c, syntax) {
+				collect(c)
+			}
+		}
+	}
+	collect(e)
+	if !syntax.rdfa {
+		// The properties of the elements referenced by itemref belong to the item as well.
+		root := rootNode(e)
+		for _, id := range strings.Fields(e.Attribute("itemref")) {
+			ref := root.QueryFunc(func(node *Node) bool { return node.ID() == id })
+			if ref == nil || ref == e {
+				continue
+			}
+			if hasItemProp(ref, syntax) {
+				item.Props = append(item.Props, newItemProps(ref, syntax, base, seen)...)
+			}
+			if !isItemScope(ref, syntax) {
+				collect(ref)
+			}
+		}
+	}
+	return item
+}
+
+func newItemProps(e *Node, syntax itemSyntax, base *url.URL, seen map[*Node]bool) (props []ItemProp) {
+	var value string
+	var nested *Item
+	if isItemScope(e, syntax) {
+		nested = newItem(e, syntax, base, seen)
+	} else {
+		value = itemValue(e, syntax, base)
+	}
+	for _, name := range strings.Fields(e.Attribute(syntax.prop)) {
+		props = append(props, ItemProp{Name: name, Value: value, Item: nested, Node: e})
+	}
+	return
+}
+
+// itemValue returns the property value of the element node, as the microdata and RDFa Lite rules.
+func itemValue(e *Node, syntax itemSyntax, base *url.URL) string {
+	if syntax.rdfa {
+		if content, ok := lookupAttr(e, "content"); ok {
+			return content
+		}
+		for _, attr := range []string{"href", "src", "resource"} {
+			if _, ok := lookupAttr(e, attr); ok {
+				return absURL(e, attr, base)
+			}
+		}
+		return collapseSpace(e.FullText())
+	}
+	switch e.Data {
+	case "meta":
+		return e.Attribute("content")
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return absURL(e, "src", base)
+	case "a", "area", "link":
+		return absURL(e, "href", base)
+	case "object":
+		return absURL(e, "data", base)
+	case "data", "meter":
+		return e.Attribute("value")
+	case "time":
+		if datetime, ok := lookupAttr(e, "datetime"); ok {
+			return datetime
+		}
+	}
+	return collapseSpace(e.FullText())
+}
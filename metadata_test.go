@@ -0,0 +1,130 @@
+package supersimplesoup
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testMetadataHTML = `<!DOCTYPE html>
+<html lang="en-US">
+<head>
+	<base href="https://example.com/blog/">
+	<title> Hello
+		World </title>
+	<meta name="Description" content=" A post. ">
+	<link rel="alternate canonical" href="hello">
+	<meta property="og:title" content="Hello">
+	<meta property="og:image" content="a.png">
+	<meta property="og:image" content="b.png">
+	<meta property="article:author" content="Alice">
+	<meta name="twitter:card" content="summary">
+	<meta property="twitter:site" content="@example">
+	<script type="application/ld+json">{"@type": "Article", "headline": "Hello"}</script>
+	<script type=" Application/LD+JSON ">{bad}</script>
+</head>
+<body>
+	<div itemscope itemtype="https://schema.org/Person" itemref="extra">
+		<span itemprop="name">Bob</span>
+		<a itemprop="url sameAs" href="/bob">home</a>
+		<time itemprop="birthDate" datetime="2000-01-02">Jan 2</time>
+		<div itemprop="address" itemscope itemtype="https://schema.org/PostalAddress">
+			<span itemprop="addressLocality">Paris</span>
+		</div>
+	</div>
+	<p id="extra"><meta itemprop="jobTitle" content="Chef"></p>
+	<div vocab="https://schema.org/" typeof="Event" resource="#ev">
+		<span property="name">Party</span>
+		<div property="location" typeof="Place"><span property="name">Hall</span></div>
+		<a property="url" href="party">link</a>
+		<meta property="startDate" content="2024-05-01">
+	</div>
+</body>
+</html>`
+
+func TestMetadata(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testMetadataHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := Metadata(doc.Query("span"), nil)
+	if meta.Title != "Hello World" || meta.Description != "A post." || meta.Canonical != "https://example.com/blog/hello" || meta.Lang != "en-US" {
+		t.Errorf("meta, got %q %q %q %q", meta.Title, meta.Description, meta.Canonical, meta.Lang)
+	}
+	if meta.OpenGraph.Get("og:title") != "Hello" || !reflect.DeepEqual(meta.OpenGraph.All("og:image"), []string{"a.png", "b.png"}) ||
+		meta.OpenGraph.Get("article:author") != "Alice" || len(meta.OpenGraph) != 4 || meta.OpenGraph[0].Node.Attribute("content") != "Hello" {
+		t.Errorf("opengraph, got %+v", meta.OpenGraph)
+	}
+	if meta.Twitter.Get("twitter:card") != "summary" || meta.Twitter.Get("twitter:site") != "@example" || len(meta.Twitter) != 2 {
+		t.Errorf("twitter, got %+v", meta.Twitter)
+	}
+	if len(meta.JSONLD) != 2 || meta.JSONLD[0].Err != nil || meta.JSONLD[1].Err == nil {
+		t.Fatalf("json-ld, got %+v", meta.JSONLD)
+	}
+	if data, ok := meta.JSONLD[0].Data.(map[string]any); !ok || data["headline"] != "Hello" {
+		t.Errorf("json-ld data, got %v", meta.JSONLD[0].Data)
+	}
+
+	if len(meta.Items) != 2 {
+		t.Fatalf("items count, want %d, got %d", 2, len(meta.Items))
+	}
+	person := meta.Items[0]
+	tests := []struct {
+		item  *Item
+		name  string
+		value string
+	}{
+		{person, "name", "Bob"},
+		{person, "url", "https://example.com/bob"},
+		{person, "sameAs", "https://example.com/bob"},
+		{person, "birthDate", "2000-01-02"},
+		{person, "jobTitle", "Chef"},
+		{person.Item("address"), "addressLocality", "Paris"},
+		{meta.Items[1], "name", "Party"},
+		{meta.Items[1], "url", "https://example.com/blog/party"},
+		{meta.Items[1], "startDate", "2024-05-01"},
+		{meta.Items[1].Item("location"), "name", "Hall"},
+	}
+	for _, test := range tests {
+		if test.item == nil {
+			t.Errorf("`%s` item not found", test.name)
+			continue
+		}
+		if got := test.item.Get(test.name); got != test.value {
+			t.Errorf("`%s` item property, want %q, got %q", test.name, test.value, got)
+		}
+	}
+	if !reflect.DeepEqual(person.Type, []string{"https://schema.org/Person"}) || len(person.Props) != 6 || person.Node.Data != "div" {
+		t.Errorf("person item, got %+v", person)
+	}
+	if event := meta.Items[1]; !reflect.DeepEqual(event.Type, []string{"https://schema.org/Event"}) || event.ID != "https://example.com/blog/#ev" ||
+		!reflect.DeepEqual(event.Item("location").Type, []string{"https://schema.org/Place"}) {
+		t.Errorf("event item, got %+v", event)
+	}
+}
+
+func TestMetadataItemrefCycle(t *testing.T) {
+	doc := parseBody(t, `<div itemscope id="a" itemref="b"></div><div id="b" itemprop="friend" itemscope itemref="a c"><span id="c" itemprop="name">x</span></div>`)
+	meta := Metadata(doc, nil)
+	if len(meta.Items) != 1 || meta.Items[0].Item("friend") == nil || meta.Items[0].Item("friend").Get("name") != "x" {
+		t.Errorf("items, got %+v", meta.Items)
+	}
+}
+
+func TestMetadataBase(t *testing.T) {
+	doc := parseBody(t, `<link rel="canonical" href="post"><div itemscope itemid="#me"><a itemprop="url" href="/bob">x</a></div>`+
+		`<div typeof="Thing" about="thing"><img property="image" src="a.png"></div>`)
+	base, _ := url.Parse("https://example.com/blog/")
+	meta := Metadata(doc, base)
+	if meta.Canonical != "https://example.com/blog/post" {
+		t.Errorf("canonical, want %q, got %q", "https://example.com/blog/post", meta.Canonical)
+	}
+	if len(meta.Items) != 2 || meta.Items[0].ID != "https://example.com/blog/#me" || meta.Items[0].Get("url") != "https://example.com/bob" ||
+		meta.Items[1].ID != "https://example.com/blog/thing" || meta.Items[1].Get("image") != "https://example.com/blog/a.png" {
+		t.Errorf("items, got %+v", meta.Items)
+	}
+	if meta := Metadata(doc, nil); meta.Canonical != "post" || meta.Items[0].ID != "#me" {
+		t.Errorf("without base, got %q %q", meta.Canonical, meta.Items[0].ID)
+	}
+}
@@ -0,0 +1,396 @@
+package supersimplesoup
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// adopt detaches child from its parent, so that it can be inserted under this node.
+//
+// It panics if child is this node or an ancestor of this node, which would make a cycle.
+func (n *Node) adopt(child *Node) {
+	for p := n; p != nil; p = p.ParentNode() {
+		if p == child {
+			panic("supersimplesoup: insert a node into itself or its descendant")
+		}
+	}
+	child.Remove()
+}
+
+// AppendChild adds child as the last child node of this node, detaching it from its parent first.
+//
+// Allow chaining call.
+func (n *Node) AppendChild(child *Node) *Node {
+	if n == nil || child == nil {
+		return n
+	}
+	n.adopt(child)
+	(*html.Node)(n).AppendChild((*html.Node)(child))
+	return n
+}
+
+// PrependChild adds child as the first child node of this node, detaching it from its parent first.
+//
+// Allow chaining call.
+func (n *Node) PrependChild(child *Node) *Node {
+	if n == nil || child == nil {
+		return n
+	}
+	n.adopt(child)
+	(*html.Node)(n).InsertBefore((*html.Node)(child), n.FirstChild)
+	return n
+}
+
+// InsertBefore adds node as the previous sibling node of this node, detaching it from its parent first.
+//
+// It does nothing if this node has no parent node.
+//
+// Allow chaining call.
+func (n *Node) InsertBefore(node *Node) *Node {
+	if n == nil || node == nil || n.Parent == nil || node == n {
+		return n
+	}
+	p := n.ParentNode()
+	p.adopt(node)
+	(*html.Node)(p).InsertBefore((*html.Node)(node), (*html.Node)(n))
+	return n
+}
+
+// InsertAfter adds node as the next sibling node of this node, detaching it from its parent first.
+//
+// It does nothing if this node has no parent node.
+//
+// Allow chaining call.
+func (n *Node) InsertAfter(node *Node) *Node {
+	if n == nil || node == nil || n.Parent == nil || node == n {
+		return n
+	}
+	p := n.ParentNode()
+	p.adopt(node)
+	(*html.Node)(p).InsertBefore((*html.Node)(node), n.NextSibling)
+	return n
+}
+
+// Remove detaches this node with its descendants from the tree.
+//
+// Allow chaining call.
+func (n *Node) Remove() *Node {
+	if n == nil || n.Parent == nil {
+		return n
+	}
+	n.Parent.RemoveChild((*html.Node)(n))
+	return n
+}
+
+// ReplaceWith puts node in the place of this node, and detaches this node from the tree.
+//
+// It does nothing if this node has no parent node.
+//
+// Allow chaining call.
+func (n *Node) ReplaceWith(node *Node) *Node {
+	if n == nil || node == nil || n.Parent == nil || node == n {
+		return n
+	}
+	n.InsertAfter(node)
+	return n.Remove()
+}
+
+// Wrap puts wrapper in the place of this node, and adds this node as the last child node of wrapper.
+//
+// Allow chaining call.
+func (n *Node) Wrap(wrapper *Node) *Node {
+	if n == nil || wrapper == nil || wrapper == n {
+		return n
+	}
+	if n.Parent != nil {
+		n.InsertBefore(wrapper)
+	}
+	wrapper.AppendChild(n)
+	return n
+}
+
+// Unwrap puts the child nodes of this node in the place of this node, and detaches this node from the tree.
+//
+// It does nothing if this node has no parent node.
+//
+// Allow chaining call.
+func (n *Node) Unwrap() *Node {
+	if n == nil || n.Parent == nil {
+		return n
+	}
+	for c := n.FirstChildNode(); c != nil; c = n.FirstChildNode() {
+		n.InsertBefore(c)
+	}
+	return n.Remove()
+}
+
+// Empty detaches all the child nodes of this node.
+//
+// Allow chaining call.
+func (n *Node) Empty() *Node {
+	if n == nil {
+		return n
+	}
+	for c := n.FirstChildNode(); c != nil; c = n.FirstChildNode() {
+		c.Remove()
+	}
+	return n
+}
+
+// SetAttribute sets the key specified attribute of this node, adding it if not exists.
+//
+// Allow chaining call.
+func (n *Node) SetAttribute(key, val string) *Node {
+	if n == nil {
+		return n
+	}
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return n
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+	return n
+}
+
+// RemoveAttribute removes the key specified attribute of this node.
+//
+// Allow chaining call.
+func (n *Node) RemoveAttribute(key string) *Node {
+	if n == nil {
+		return n
+	}
+	attrs := n.Attr[:0]
+	for _, attr := range n.Attr {
+		if attr.Key != key {
+			attrs = append(attrs, attr)
+		}
+	}
+	n.Attr = attrs
+	return n
+}
+
+func (n *Node) setClasses(classes []string) *Node {
+	if len(classes) == 0 {
+		return n.RemoveAttribute("class")
+	}
+	return n.SetAttribute("class", strings.Join(classes, " "))
+}
+
+// AddClass adds the classes to the class attribute of this node, which are not in it yet.
+//
+// Allow chaining call.
+func (n *Node) AddClass(classes ...string) *Node {
+	if n == nil {
+		return n
+	}
+	current := strings.Fields(n.Class())
+	for _, class := range classes {
+		if !containsString(current, class) {
+			current = append(current, class)
+		}
+	}
+	return n.setClasses(current)
+}
+
+// RemoveClass removes the classes from the class attribute of this node.
+//
+// Allow chaining call.
+func (n *Node) RemoveClass(classes ...string) *Node {
+	if n == nil {
+		return n
+	}
+	var kept []string
+	for _, class := range strings.Fields(n.Class()) {
+		if !containsString(classes, class) {
+			kept = append(kept, class)
+		}
+	}
+	return n.setClasses(kept)
+}
+
+// ToggleClass removes the class from the class attribute of this node if it is in it, otherwise adds it.
+//
+// Allow chaining call.
+func (n *Node) ToggleClass(class string) *Node {
+	if n == nil {
+		return n
+	}
+	if containsString(strings.Fields(n.Class()), class) {
+		return n.RemoveClass(class)
+	}
+	return n.AddClass(class)
+}
+
+// SetText replaces all the child nodes of this node with a text node of text.
+//
+// Allow chaining call.
+func (n *Node) SetText(text string) *Node {
+	if n == nil {
+		return n
+	}
+	n.Empty()
+	(*html.Node)(n).AppendChild(&html.Node{Type: html.TextNode, Data: text})
+	return n
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// withEach calls f with each node of this nodes and node, or a deep clone of node, since a node can only be in one place.
+// The last node of this nodes gets node itself, and the others get the clones.
+func (ns Nodes) withEach(node *Node, f func(n, node *Node)) {
+	for i, n := range ns {
+		if i < len(ns)-1 {
+			f(n, node.Clone(true))
+		} else {
+			f(n, node)
+		}
+	}
+}
+
+// AppendChild adds child as the last child node of each node of this nodes, where the nodes but the last get its deep clones.
+//
+// Allow chaining call.
+func (ns Nodes) AppendChild(child *Node) Nodes {
+	ns.withEach(child, func(n, child *Node) { n.AppendChild(child) })
+	return ns
+}
+
+// PrependChild adds child as the first child node of each node of this nodes, where the nodes but the last get its deep clones.
+//
+// Allow chaining call.
+func (ns Nodes) PrependChild(child *Node) Nodes {
+	ns.withEach(child, func(n, child *Node) { n.PrependChild(child) })
+	return ns
+}
+
+// InsertBefore adds node as the previous sibling node of each node of this nodes, where the nodes but the last get its deep clones.
+//
+// Allow chaining call.
+func (ns Nodes) InsertBefore(node *Node) Nodes {
+	ns.withEach(node, func(n, node *Node) { n.InsertBefore(node) })
+	return ns
+}
+
+// InsertAfter adds node as the next sibling node of each node of this nodes, where the nodes but the last get its deep clones.
+//
+// Allow chaining call.
+func (ns Nodes) InsertAfter(node *Node) Nodes {
+	ns.withEach(node, func(n, node *Node) { n.InsertAfter(node) })
+	return ns
+}
+
+// ReplaceWith puts node in the place of each node of this nodes, where the nodes but the last get its deep clones,
+// and detaches each node of this nodes from the tree.
+//
+// Allow chaining call.
+func (ns Nodes) ReplaceWith(node *Node) Nodes {
+	ns.withEach(node, func(n, node *Node) { n.ReplaceWith(node) })
+	return ns
+}
+
+// Wrap wraps each node of this nodes in wrapper, where the nodes but the last get its deep clones.
+//
+// Allow chaining call.
+func (ns Nodes) Wrap(wrapper *Node) Nodes {
+	ns.withEach(wrapper, func(n, wrapper *Node) { n.Wrap(wrapper) })
+	return ns
+}
+
+// Remove detaches each node of this nodes from the tree.
+//
+// Allow chaining call.
+func (ns Nodes) Remove() Nodes {
+	for _, n := range ns {
+		n.Remove()
+	}
+	return ns
+}
+
+// Unwrap puts the child nodes of each node of this nodes in the place of it, and detaches it from the tree.
+//
+// Allow chaining call.
+func (ns Nodes) Unwrap() Nodes {
+	for _, n := range ns {
+		n.Unwrap()
+	}
+	return ns
+}
+
+// Empty detaches all the child nodes of each node of this nodes.
+//
+// Allow chaining call.
+func (ns Nodes) Empty() Nodes {
+	for _, n := range ns {
+		n.Empty()
+	}
+	return ns
+}
+
+// SetAttribute sets the key specified attribute of each node of this nodes.
+//
+// Allow chaining call.
+func (ns Nodes) SetAttribute(key, val string) Nodes {
+	for _, n := range ns {
+		n.SetAttribute(key, val)
+	}
+	return ns
+}
+
+// RemoveAttribute removes the key specified attribute of each node of this nodes.
+//
+// Allow chaining call.
+func (ns Nodes) RemoveAttribute(key string) Nodes {
+	for _, n := range ns {
+		n.RemoveAttribute(key)
+	}
+	return ns
+}
+
+// AddClass adds the classes to the class attribute of each node of this nodes.
+//
+// Allow chaining call.
+func (ns Nodes) AddClass(classes ...string) Nodes {
+	for _, n := range ns {
+		n.AddClass(classes...)
+	}
+	return ns
+}
+
+// RemoveClass removes the classes from the class attribute of each node of this nodes.
+//
+// Allow chaining call.
+func (ns Nodes) RemoveClass(classes ...string) Nodes {
+	for _, n := range ns {
+		n.RemoveClass(classes...)
+	}
+	return ns
+}
+
+// ToggleClass toggles the class in the class attribute of each node of this nodes.
+//
+// Allow chaining call.
+func (ns Nodes) ToggleClass(class string) Nodes {
+	for _, n := range ns {
+		n.ToggleClass(class)
+	}
+	return ns
+}
+
+// SetText replaces all the child nodes of each node of this nodes with a text node of text.
+//
+// Allow chaining call.
+func (ns Nodes) SetText(text string) Nodes {
+	for _, n := range ns {
+		n.SetText(text)
+	}
+	return ns
+}
@@ -0,0 +1,105 @@
+package supersimplesoup
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func parseBody(t *testing.T, s string) *Node {
+	t.Helper()
+	doc, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.Query("body")
+}
+
+func innerHTML(n *Node) string {
+	var buf strings.Builder
+	for c := n.FirstChildNode(); c != nil; c = c.NextSiblingNode() {
+		buf.WriteString(c.HTML())
+	}
+	return buf.String()
+}
+
+func newTestElement(tag string) *Node {
+	return &Node{Type: html.ElementNode, Data: tag}
+}
+
+func TestMutate(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		mutate func(body *Node)
+		want   string
+	}{
+		{"append-child", `<p>a</p>`, func(body *Node) { body.AppendChild(newTestElement("hr")) }, `<p>a</p><hr/>`},
+		{"prepend-child", `<p>a</p>`, func(body *Node) { body.PrependChild(newTestElement("hr")) }, `<hr/><p>a</p>`},
+		{"move-child", `<p>a</p><i>b</i>`, func(body *Node) { body.Query("p").AppendChild(body.Query("i")) }, `<p>a<i>b</i></p>`},
+		{"insert-before", `<p>a</p>`, func(body *Node) { body.Query("p").InsertBefore(newTestElement("hr")) }, `<hr/><p>a</p>`},
+		{"insert-after", `<p>a</p><i>b</i>`, func(body *Node) { body.Query("p").InsertAfter(newTestElement("hr")) }, `<p>a</p><hr/><i>b</i>`},
+		{"remove", `<p>a</p><script>x</script><i>b</i><script>y</script>`, func(body *Node) { body.QueryAll("script").Remove() }, `<p>a</p><i>b</i>`},
+		{"replace-with", `<p>a</p><i>b</i>`, func(body *Node) { body.Query("p").ReplaceWith(newTestElement("hr")) }, `<hr/><i>b</i>`},
+		{"wrap", `<p>a</p><i>b</i>`, func(body *Node) { body.Query("i").Wrap(newTestElement("div")) }, `<p>a</p><div><i>b</i></div>`},
+		{"unwrap", `<div><p>a</p>b</div>`, func(body *Node) { body.Query("div").Unwrap() }, `<p>a</p>b`},
+		{"empty", `<div><p>a</p>b</div>`, func(body *Node) { body.Query("div").Empty() }, `<div></div>`},
+		{"set-text", `<p>a<i>b</i></p><p>c</p>`, func(body *Node) { body.QueryAll("p").SetText("<x>") }, `<p>&lt;x&gt;</p><p>&lt;x&gt;</p>`},
+		{"set-attribute", `<a id="x">a</a>`, func(body *Node) { body.Query("a").SetAttribute("id", "y").SetAttribute("href", "z") }, `<a id="y" href="z">a</a>`},
+		{"remove-attribute", `<a id="x" href="z">a</a>`, func(body *Node) { body.Query("a").RemoveAttribute("id") }, `<a href="z">a</a>`},
+		{"add-class", `<a class="x">a</a>`, func(body *Node) { body.Query("a").AddClass("x", "y") }, `<a class="x y">a</a>`},
+		{"remove-class", `<a class="x y">a</a>`, func(body *Node) { body.Query("a").RemoveClass("x").RemoveClass("y") }, `<a>a</a>`},
+		{"bulk-append-child", `<p>a</p><p>b</p>`, func(body *Node) { body.QueryAll("p").AppendChild(newTestElement("hr")) }, `<p>a<hr/></p><p>b<hr/></p>`},
+		{"bulk-prepend-child", `<p>a</p><p>b</p>`, func(body *Node) { body.QueryAll("p").PrependChild(newTestElement("hr")) }, `<p><hr/>a</p><p><hr/>b</p>`},
+		{"bulk-insert-before", `<p>a</p><p>b</p>`, func(body *Node) { body.QueryAll("p").InsertBefore(newTestElement("hr")) }, `<hr/><p>a</p><hr/><p>b</p>`},
+		{"bulk-insert-after", `<p>a</p><p>b</p>`, func(body *Node) { body.QueryAll("p").InsertAfter(newTestElement("hr")) }, `<p>a</p><hr/><p>b</p><hr/>`},
+		{"bulk-replace-with", `<p>a</p><i>b</i><p>c</p>`, func(body *Node) { body.QueryAll("p").ReplaceWith(newTestElement("hr")) }, `<hr/><i>b</i><hr/>`},
+		{"bulk-wrap", `<p>a</p><i>b</i><p>c</p>`, func(body *Node) { body.QueryAll("p").Wrap(newTestElement("div").SetAttribute("class", "w")) },
+			`<div class="w"><p>a</p></div><i>b</i><div class="w"><p>c</p></div>`},
+		{"toggle-class", `<a class="x">a</a><a>b</a>`, func(body *Node) { body.QueryAll("a").ToggleClass("x") }, `<a>a</a><a class="x">b</a>`},
+	}
+
+	for _, test := range tests {
+		body := parseBody(t, test.src)
+		test.mutate(body)
+		if got := innerHTML(body); test.want != got {
+			t.Errorf("`%s` html, want %q, got %q", test.name, test.want, got)
+		}
+	}
+}
+
+func TestMutateWalk(t *testing.T) {
+	body := parseBody(t, `<ul><li>a</li><li>b</li><li>c</li></ul>`)
+	lis := body.QueryAll("li")
+	lis[2].InsertBefore(lis[0])
+	var got []string
+	Walk(body, func(node *Node) error {
+		if node.IsTextNode() {
+			got = append(got, node.Data)
+		}
+		return nil
+	})
+	if want := "bac"; strings.Join(got, "") != want {
+		t.Errorf("walk text, want %q, got %q", want, strings.Join(got, ""))
+	}
+	if lis[0].ParentNode() != body.Query("ul") || lis[0].PrevSiblingNode() != lis[1] || lis[0].NextSiblingNode() != lis[2] {
+		t.Errorf("moved node links are inconsistent")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("append ancestor, want panic")
+		}
+	}()
+	lis[0].AppendChild(body)
+}
+
+func TestMutateNodesClone(t *testing.T) {
+	body := parseBody(t, `<p>a</p><p>b</p><p>c</p>`)
+	ps := body.QueryAll("p")
+	hr := newTestElement("hr")
+	ps.AppendChild(hr)
+	if hr.ParentNode() != ps[2] || ps[0].LastChildNode() == hr || ps[0].LastChildNode().Data != "hr" {
+		t.Errorf("bulk append, want the node itself in the last and clones in the others")
+	}
+}
@@ -1,5 +1,25 @@
 /*
 Package supersimplesoup implements a super simple soup like DOM API.
+
+The query methods such as Find, Query and QueryAll accept the optional attribute key and value pairs,
+and the node is matched only if all the pairs are matched. A key without value matches the node which has the attribute.
+A plain value matches the attribute equal to it or containing all its whitespace separated tokens.
+The value may start with an operator to change how it is matched:
+
+	=v    the attribute is equal to v
+	~=v   the attribute contains the whitespace separated token v
+	|=v   the attribute is equal to v or starts with v followed by a hyphen
+	^=v   the attribute starts with v
+	$=v   the attribute ends with v
+	*=v   the attribute contains v
+	%=v   the attribute is matched by the regular expression v
+
+An operator prefixed with i, e.g. i^=v, matches case-insensitively.
+A value starting with a backslash is matched as the plain value after it, e.g. \=v matches the attribute equal to =v.
+The query methods panic if the regular expression is invalid, except Find which returns the error.
+
+The Select methods accept a CSS selector, and panic if the selector is invalid as the query methods do.
+Compile returns the error of an invalid selector instead.
 */
 package supersimplesoup
 
@@ -24,7 +44,7 @@ type Node html.Node
 
 // Parse returns the parse tree for the HTML from the given Reader.
 //
-// The input is assumed to be UTF-8 encoded.
+// The input is assumed to be UTF-8 encoded, use ParseWithCharset for the input in other encodings.
 func Parse(r io.Reader) (*Node, error) {
 	if n, err := html.Parse(r); err != nil {
 		return nil, err
@@ -33,6 +53,28 @@ func Parse(r io.Reader) (*Node, error) {
 	}
 }
 
+// ParseFragment parses a fragment of HTML and returns the nodes that were found,
+// as if it were the inner HTML of the context element node.
+//
+// If context is nil, the fragment is parsed as the inner HTML of a body element.
+// The returned nodes are detached, so they can be inserted into another tree.
+//
+// The input is assumed to be UTF-8 encoded.
+func ParseFragment(r io.Reader, context *Node) (Nodes, error) {
+	if context == nil {
+		context = NewElement("body")
+	}
+	ns, err := html.ParseFragment(r, (*html.Node)(context))
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(Nodes, 0, len(ns))
+	for _, n := range ns {
+		nodes = append(nodes, (*Node)(n))
+	}
+	return nodes, nil
+}
+
 // WalkFunc is the type of the function called by Walk to visit each node.
 //
 // The error result returned by the function controls how Walk continues.
@@ -162,30 +204,14 @@ func (n *Node) HTML() string {
 	return buf.String()
 }
 
-// Text returns the text joined by all the direct child text nodes of this node.
-func (n *Node) Text() (text string) {
-	for _, c := range n.ChildrenNodes() {
-		if !c.IsTextNode() {
-			continue
-		}
-		if blankRegexp.MatchString(c.Data) {
-			continue
-		}
-		text = text + c.Data
-	}
-	return
+// Text returns the text joined by all the direct child text nodes of this node, skipping the blank ones.
+func (n *Node) Text() string {
+	return n.TextWith(TextOptions{SkipBlank: true})
 }
 
-// Text returns the text joined by all the child text nodes in depth order of this node.
+// FullText returns the text joined by all the text nodes in depth order of this node, including this node.
 func (n *Node) FullText() string {
-	var buf bytes.Buffer
-	n.Walk(func(node *Node) error {
-		if node.IsTextNode() {
-			buf.WriteString(node.Data)
-		}
-		return nil
-	})
-	return buf.String()
+	return n.TextWith(TextOptions{Deep: true})
 }
 
 // Walk walks the node tree rooted at this node, calling fn for each node in the tree, including this node.
@@ -193,21 +219,28 @@ func (n *Node) Walk(fn WalkFunc) error {
 	return Walk(n, fn)
 }
 
-// Find returns the first child element node matched by the specified tag and optional attribute key and value of this node.
+// Find returns the first child element node matched by the specified tag and optional attribute key and value pairs of this node.
 //
 // It returns an error if no child element node is matched.
 func (n *Node) Find(tag string, attrkv ...string) (*Node, error) {
 	if n == nil {
 		return nil, fmt.Errorf("not allow to find on a blank node")
 	}
-	if ns := query(n, tag, attrkv, 1); len(ns) > 0 {
+	conds, err := plainAttrs(attrkv)
+	if err != nil {
+		return nil, err
+	}
+	if ns := queryConds(n, tag, conds, 1); len(ns) > 0 {
 		return ns[0], nil
 	} else {
+		if pos := n.Position(); pos.IsValid() {
+			return nil, fmt.Errorf("not found element `%s` in `%s` at %s", prettyTagAttr(tag, attrkv), n.Data, pos)
+		}
 		return nil, fmt.Errorf("not found element `%s`", prettyTagAttr(tag, attrkv))
 	}
 }
 
-// Query returns the first child element node matched by the specified tag and optional attribute key and value of this node.
+// Query returns the first child element node matched by the specified tag and optional attribute key and value pairs of this node.
 //
 // It returns nil if no child element node is matched.
 //
@@ -223,7 +256,7 @@ func (n *Node) Query(tag string, attrkv ...string) *Node {
 	}
 }
 
-// QueryAll returns the child element nodes matched by the specified tag and optional attribute key and value of this node.
+// QueryAll returns the child element nodes matched by the specified tag and optional attribute key and value pairs of this node.
 //
 // It returns nil if no child element node is matched.
 //
@@ -235,9 +268,37 @@ func (n *Node) QueryAll(tag string, attrkv ...string) Nodes {
 	return query(n, tag, attrkv, 0)
 }
 
+// QueryFunc returns the first child element node matched by the specified function of this node.
+//
+// It returns nil if no child element node is matched.
+//
+// Allow chaining call.
+func (n *Node) QueryFunc(fn MatchFunc) *Node {
+	if n == nil {
+		return nil
+	}
+	if ns := queryFunc(n, fn, 1); len(ns) > 0 {
+		return ns[0]
+	} else {
+		return nil
+	}
+}
+
+// QueryAllFunc returns the child element nodes matched by the specified function of this node.
+//
+// It returns nil if no child element node is matched.
+//
+// Allow chaining call.
+func (n *Node) QueryAllFunc(fn MatchFunc) Nodes {
+	if n == nil {
+		return nil
+	}
+	return queryFunc(n, fn, 0)
+}
+
 type Nodes []*Node
 
-// Query returns all the first child element node matched by the specified tag and optional attribute key and value on each node of this nodes.
+// Query returns all the first child element node matched by the specified tag and optional attribute key and value pairs on each node of this nodes.
 //
 // It returns nil if no child element node is matched.
 //
@@ -254,7 +315,7 @@ func (ns Nodes) Query(tag string, attrkv ...string) (found Nodes) {
 	return
 }
 
-// QueryAll returns all the child element nodes matched by the specified tag and optional attribute key and value on each node of this nodes.
+// QueryAll returns all the child element nodes matched by the specified tag and optional attribute key and value pairs on each node of this nodes.
 //
 // It returns nil if no child element node is matched.
 //
@@ -271,31 +332,134 @@ func (ns Nodes) QueryAll(tag string, attrkv ...string) (found Nodes) {
 	return
 }
 
-func plainAttr(attrkv []string) (string, string) {
-	if n := len(attrkv); n == 0 {
-		return "", ""
-	} else if n >= 2 {
-		return attrkv[0], attrkv[1]
-	} else {
-		return attrkv[0], ""
+// QueryFunc returns all the first child element node matched by the specified function on each node of this nodes.
+//
+// It returns nil if no child element node is matched.
+//
+// Allow chaining call.
+func (ns Nodes) QueryFunc(fn MatchFunc) (found Nodes) {
+	if ns == nil {
+		return
+	}
+	for _, n := range ns {
+		found = append(found, queryFunc(n, fn, 1)...)
+	}
+	return
+}
+
+// QueryAllFunc returns all the child element nodes matched by the specified function on each node of this nodes.
+//
+// It returns nil if no child element node is matched.
+//
+// Allow chaining call.
+func (ns Nodes) QueryAllFunc(fn MatchFunc) (found Nodes) {
+	if ns == nil {
+		return
+	}
+	for _, n := range ns {
+		found = append(found, queryFunc(n, fn, 0)...)
+	}
+	return
+}
+
+// attrCond is a parsed attribute key and value pair of the query.
+type attrCond struct {
+	key  string
+	op   string
+	val  string
+	fold bool
+	re   *regexp.Regexp
+}
+
+// attrOps is the operator prefixes of the attribute value, the longer ones go first.
+var attrOps = []string{"~=", "|=", "^=", "$=", "*=", "%=", "="}
+
+func plainAttrs(attrkv []string) (conds []attrCond, err error) {
+	for i := 0; i < len(attrkv); i += 2 {
+		cond := attrCond{key: attrkv[i]}
+		if i+1 < len(attrkv) {
+			cond.val = attrkv[i+1]
+		}
+		if cond.key == "" {
+			continue
+		}
+		if strings.HasPrefix(cond.val, `\`) {
+			// The escaped value is plain even if it starts with an operator.
+			cond.val = cond.val[1:]
+			conds = append(conds, cond)
+			continue
+		}
+		val := cond.val
+		fold := strings.HasPrefix(val, "i")
+		if fold {
+			val = val[1:]
+		}
+		for _, op := range attrOps {
+			if strings.HasPrefix(val, op) {
+				cond.op, cond.val, cond.fold = op, val[len(op):], fold
+				break
+			}
+		}
+		if cond.op == "%=" {
+			expr := cond.val
+			if cond.fold {
+				expr = "(?i)" + expr
+			}
+			if cond.re, err = regexp.Compile(expr); err != nil {
+				return nil, fmt.Errorf("invalid regular expression of attribute `%s`: %w", cond.key, err)
+			}
+		}
+		conds = append(conds, cond)
 	}
+	return
+}
+
+// mustPlainAttrs is like plainAttrs but panics if the regular expression is invalid.
+func mustPlainAttrs(attrkv []string) []attrCond {
+	conds, err := plainAttrs(attrkv)
+	if err != nil {
+		panic(err)
+	}
+	return conds
 }
 
 func prettyTagAttr(tag string, attrkv []string) string {
-	key, val := plainAttr(attrkv)
-	if key == "" && val == "" {
+	conds, _ := plainAttrs(attrkv)
+	if len(conds) == 0 {
 		return tag
-	} else {
-		return fmt.Sprintf("%s[%s=%s]", tag, key, val)
 	}
+	var buf strings.Builder
+	buf.WriteString(tag)
+	for _, cond := range conds {
+		op := cond.op
+		if op == "" {
+			op = "="
+		}
+		if cond.fold {
+			fmt.Fprintf(&buf, "[%s%s%s i]", cond.key, op, cond.val)
+		} else {
+			fmt.Fprintf(&buf, "[%s%s%s]", cond.key, op, cond.val)
+		}
+	}
+	return buf.String()
 }
 
-func query(n *Node, tag string, attrkv []string, m int) (found Nodes) {
+func query(n *Node, tag string, attrkv []string, m int) Nodes {
+	return queryConds(n, tag, mustPlainAttrs(attrkv), m)
+}
+
+func queryConds(n *Node, tag string, conds []attrCond, m int) Nodes {
+	return queryFunc(n, func(node *Node) bool {
+		return match(node, tag, conds)
+	}, m)
+}
+
+func queryFunc(n *Node, fn MatchFunc, m int) (found Nodes) {
 	Walk(n, func(node *Node) error {
-		if node == n {
+		if node == n || !node.IsElementNode() {
 			return nil
 		}
-		if match(node, tag, attrkv) {
+		if fn(node) {
 			found = append(found, node)
 			if m > 0 && len(found) >= m {
 				return SkipAll
@@ -306,29 +470,42 @@ func query(n *Node, tag string, attrkv []string, m int) (found Nodes) {
 	return
 }
 
-func match(n *Node, tag string, attrkv []string) bool {
+func match(n *Node, tag string, conds []attrCond) bool {
 	if !n.IsElementNode() {
 		return false
 	}
 	if tag != "" && tag != n.Data {
 		return false
 	}
-	key, val := plainAttr(attrkv)
-	if key == "" {
-		return true
+	for _, cond := range conds {
+		if !matchAttr(n, cond) {
+			return false
+		}
 	}
+	return true
+}
+
+func matchAttr(n *Node, cond attrCond) bool {
 	for i := 0; i < len(n.Attr); i++ {
-		if key != n.Attr[i].Key {
+		if cond.key != n.Attr[i].Key {
 			continue
 		}
-		if val == "" || val == n.Attr[i].Val {
+		val := n.Attr[i].Val
+		switch cond.op {
+		case "":
+		case "%=":
+			return cond.re != nil && cond.re.MatchString(val)
+		default:
+			return matchAttrValue(cond.op, val, cond.val, cond.fold)
+		}
+		if cond.val == "" || cond.val == val {
 			return true
 		}
 		vals := make(map[string]bool)
-		for _, g := range strings.Fields(n.Attr[i].Val) {
+		for _, g := range strings.Fields(val) {
 			vals[g] = true
 		}
-		for _, f := range strings.Fields(val) {
+		for _, f := range strings.Fields(cond.val) {
 			if !vals[f] {
 				return false
 			}
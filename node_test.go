@@ -454,3 +454,88 @@ func TestAttribute(t *testing.T) {
 		}
 	}
 }
+
+func TestQueryAllAttrs(t *testing.T) {
+	tests := []struct {
+		tag    string
+		attrkv []string
+		want   int
+	}{
+		{"a", []string{"class", "a-class-1", "title", "a-title-2"}, 1},
+		{"a", []string{"class", "a-class-1", "title", "a-title-5"}, 0},
+		{"a", []string{"class", "a-class-2", "href"}, 4},
+		{"a", []string{"class", "a-class-2", "rel"}, 0},
+		{"a", []string{"href", "=a-href-1"}, 1},
+		{"a", []string{"class", "=a-class"}, 0},
+		{"a", []string{"class", "~=a-class-1"}, 4},
+		{"a", []string{"class", "|=a"}, 8},
+		{"a", []string{"href", "^=a-href"}, 8},
+		{"a", []string{"href", "$=-3"}, 1},
+		{"a", []string{"title", "*=title-"}, 8},
+		{"a", []string{"title", "i*=TITLE-"}, 8},
+		{"a", []string{"title", "*=TITLE-"}, 0},
+		{"a", []string{"id", `%=^a-id-[1-3]$`}, 3},
+		{"a", []string{"id", `i%=^A-ID-[1-3]$`}, 3},
+		{"a", []string{"title", `\=a-title-1`}, 0},
+		{"a", []string{"title", `\a-title-1`}, 1},
+		{"", []string{"id", "^=li-", "class", "$=-2"}, 2},
+	}
+
+	for _, test := range tests {
+		nodes := root.QueryAll(test.tag, test.attrkv...)
+		got := len(nodes)
+		if test.want != got {
+			t.Errorf("`%s` element node count, want %d, got %d", prettyTagAttr(test.tag, test.attrkv), test.want, got)
+		}
+	}
+	doc := parseBody(t, `<a title="=x">1</a><a title="x">2</a><a title="^=x">3</a>`)
+	escapes := []struct {
+		val  string
+		want string
+	}{
+		{"=x", "2"},
+		{`\=x`, "1"},
+		{`\^=x`, "3"},
+		{`\\x`, ""},
+	}
+	for _, test := range escapes {
+		if got := doc.Query("a", "title", test.val).Text(); test.want != got {
+			t.Errorf("`%s` element node text, want %q, got %q", test.val, test.want, got)
+		}
+	}
+
+	if _, err := root.Find("a", "id", "%=("); err == nil || !strings.Contains(err.Error(), "invalid regular expression of attribute `id`") {
+		t.Errorf("find with invalid regular expression, want error, got %v", err)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("query all with invalid regular expression, want panic")
+		}
+	}()
+	root.QueryAll("a", "id", "%=(")
+}
+
+func TestPrettyTagAttr(t *testing.T) {
+	tests := []struct {
+		tag    string
+		attrkv []string
+		want   string
+	}{
+		{"a", nil, "a"},
+		{"a", []string{"id"}, "a[id=]"},
+		{"a", []string{"id", "x"}, "a[id=x]"},
+		{"a", []string{"class", "x", "rel", "nofollow"}, "a[class=x][rel=nofollow]"},
+		{"a", []string{"href", "$=.pdf", "title", "i*=download"}, "a[href$=.pdf][title*=download i]"},
+	}
+
+	for _, test := range tests {
+		if got := prettyTagAttr(test.tag, test.attrkv); test.want != got {
+			t.Errorf("pretty tag attr, want %q, got %q", test.want, got)
+		}
+	}
+
+	_, err := root.Find("a", "class", "a-class-1", "rel", "nofollow")
+	if want := "not found element `a[class=a-class-1][rel=nofollow]`"; err == nil || err.Error() != want {
+		t.Errorf("find error, want %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,394 @@
+package supersimplesoup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+	"io"
+	"unicode/utf8"
+)
+
+var (
+	ErrTooLarge     = errors.New("input is too large")
+	ErrTooDeep      = errors.New("input is nested too deep")
+	ErrTooManyNodes = errors.New("input has too many nodes")
+)
+
+// ParseOptions controls how ParseWithOptions parses the HTML.
+type ParseOptions struct {
+	// DetectCharset makes the parser detect the character encoding of the input and transcode it to UTF-8,
+	// as ParseWithCharset. The name of the detected encoding is returned by ParseWithOptions.
+	DetectCharset bool
+	// ContentType is the optional Content-Type header of the input, used to detect the character encoding.
+	ContentType string
+	// MaxBytes is the max number of bytes read from the input. Zero means no limit.
+	MaxBytes int64
+	// MaxDepth is the max depth of the element, text, comment and doctype nodes in the input, where the top level is at depth 1.
+	// The depth is counted over the tokens, the elements closed implicitly by the HTML syntax, e.g. a li by the next li,
+	// are not nested, and html, head and body are not counted. Zero means no limit.
+	MaxDepth int
+	// MaxNodes is the max number of the element, text, comment and doctype nodes in the input, counted over the tokens.
+	// Zero means no limit.
+	MaxNodes int
+	// RecordPositions makes the parser record where the start and end tags of each element node appeared in the input,
+	// which are returned by Node.Position and Node.EndPosition.
+	RecordPositions bool
+}
+
+// ParseWithOptions returns the parse tree for the HTML from the given Reader with the specified options,
+// and the name of the character encoding detected if DetectCharset is set, or empty string otherwise.
+//
+// It stops reading the input and returns the error of ctx once ctx is done,
+// or returns an error wrapping ErrTooLarge once more than MaxBytes bytes are read.
+// MaxDepth and MaxNodes are checked as the input is tokenized, before the nodes are built,
+// and it stops reading the input and returns an error wrapping ErrTooDeep or ErrTooManyNodes once either is exceeded.
+func ParseWithOptions(ctx context.Context, r io.Reader, opts ParseOptions) (*Node, string, error) {
+	r = &parseReader{ctx: ctx, r: r, max: opts.MaxBytes}
+	var name string
+	if opts.DetectCharset {
+		br := bufio.NewReaderSize(r, sniffLen)
+		content, _ := br.Peek(sniffLen)
+		var e encoding.Encoding
+		var certain bool
+		e, name, certain = charset.DetermineEncoding(content, opts.ContentType)
+		if !certain && name == "windows-1252" && isASCII(content) && !bytes.Contains(bytes.ToLower(content), []byte("charset")) {
+			// Nothing is declared and the prescanned bytes tell nothing, which is more likely UTF-8 than windows-1252 nowadays.
+			e, name = unicode.UTF8, "utf-8"
+		}
+		if name == "utf-8" {
+			if bytes.HasPrefix(content, utf8BOM) {
+				br.Discard(len(utf8BOM))
+			}
+			r = br
+		} else {
+			// The BOM is consumed by the decoder, as charset.NewReader.
+			r = transform.NewReader(br, unicode.BOMOverride(e.NewDecoder()))
+		}
+	}
+	var tr *tokenReader
+	if opts.MaxDepth > 0 || opts.MaxNodes > 0 || opts.RecordPositions {
+		tr = newTokenReader(r, opts)
+		r = tr
+	}
+	n, err := Parse(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if opts.RecordPositions {
+		tr.applyPositions(n)
+	}
+	return n, name, nil
+}
+
+// ParseWithCharset returns the parse tree for the HTML from the given Reader and the name of its character encoding,
+// which is detected from the BOM, the optional Content-Type header and the meta elements, as the HTML5 encoding sniffing algorithm.
+//
+// The input is transcoded to UTF-8 and the BOM is removed. If no encoding is declared, it is assumed to be UTF-8 encoded
+// if the prescanned bytes are valid UTF-8 or all ASCII, or windows-1252 encoded otherwise.
+func ParseWithCharset(r io.Reader, contentType string) (*Node, string, error) {
+	return ParseWithOptions(context.Background(), r, ParseOptions{DetectCharset: true, ContentType: contentType})
+}
+
+// parseReader is the Reader which stops reading once ctx is done or more than max bytes are read.
+type parseReader struct {
+	ctx context.Context
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+func (r *parseReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if r.max > 0 && int64(len(p)) > r.max-r.n+1 {
+		p = p[:r.max-r.n+1]
+	}
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.max > 0 && r.n > r.max {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, r.max)
+	}
+	return n, err
+}
+
+// tokenReader is the Reader which passes the input through a tokenizer, and stops reading once the depth
+// or the number of the nodes in the input is more than the max. It records the positions of the tags as well if asked.
+type tokenReader struct {
+	z        *html.Tokenizer
+	buf      []byte
+	err      error
+	open     openElements // the ids are the indexes of the spans, or -1 if not recorded
+	nodes    int
+	maxDepth int
+	maxNodes int
+	record   bool
+	pos      Position
+	spans    []nodeSpan
+}
+
+func newTokenReader(r io.Reader, opts ParseOptions) *tokenReader {
+	return &tokenReader{
+		z:        html.NewTokenizer(r),
+		maxDepth: opts.MaxDepth,
+		maxNodes: opts.MaxNodes,
+		record:   opts.RecordPositions,
+		pos:      Position{Line: 1, Column: 1},
+	}
+}
+
+func (r *tokenReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.next()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *tokenReader) next() {
+	r.open.follow(r.z)
+	tt := r.z.Next()
+	if tt == html.ErrorToken {
+		r.err = r.z.Err()
+		return
+	}
+	// The raw bytes stay valid until the next token, which is not read before they are consumed.
+	// The tag name in them is lowercased by the tokenizer, which makes no difference to the parser.
+	r.buf = r.z.Raw()
+	pos := r.pos
+	if r.record {
+		r.advance()
+	}
+	switch tt {
+	case html.StartTagToken, html.SelfClosingTagToken:
+		name, _ := r.z.TagName()
+		tag := string(name)
+		id := -1
+		if r.record {
+			id = len(r.spans)
+			r.spans = append(r.spans, nodeSpan{start: pos})
+			r.mark(len(name), id)
+		}
+		if isRootTag(tag) {
+			r.open.push(tag, id)
+			return
+		}
+		r.open.closeImplied(tag)
+		r.add()
+		r.open.followStart(r.z)
+		if r.open.keepsOpen(tag, tt == html.SelfClosingTagToken) {
+			r.open.push(tag, id)
+		}
+	case html.EndTagToken:
+		name, _ := r.z.TagName()
+		if i := r.open.index(string(name)); i >= 0 {
+			if id := r.open.ids[i]; id >= 0 {
+				r.spans[id].end = pos
+			}
+			r.open.truncate(i)
+		}
+	default:
+		r.add()
+	}
+}
+
+// add counts a node at the current depth.
+func (r *tokenReader) add() {
+	r.nodes++
+	if r.maxDepth > 0 && r.open.depth()+1 > r.maxDepth {
+		r.err = fmt.Errorf("%w: deeper than %d", ErrTooDeep, r.maxDepth)
+	} else if r.maxNodes > 0 && r.nodes > r.maxNodes {
+		r.err = fmt.Errorf("%w: more than %d nodes", ErrTooManyNodes, r.maxNodes)
+	}
+	if r.err != nil {
+		r.buf = nil
+	}
+}
+
+// openElements is the stack of the elements opened by the start tags in the token stream,
+// which are closed by the end tags and implicitly by the start tags as the common rules of the HTML syntax.
+type openElements struct {
+	tags  []string
+	ids   []int // the ids of the elements given by push
+	roots int   // the number of html, head and body in the stack
+}
+
+func isRootTag(tag string) bool {
+	return tag == "html" || tag == "head" || tag == "body"
+}
+
+func (o *openElements) push(tag string, id int) {
+	if isRootTag(tag) {
+		o.roots++
+	}
+	o.tags = append(o.tags, tag)
+	o.ids = append(o.ids, id)
+}
+
+func (o *openElements) truncate(n int) {
+	for _, tag := range o.tags[n:] {
+		if isRootTag(tag) {
+			o.roots--
+		}
+	}
+	o.tags, o.ids = o.tags[:n], o.ids[:n]
+}
+
+// depth returns the number of the open elements except html, head and body.
+func (o *openElements) depth() int {
+	return len(o.tags) - o.roots
+}
+
+// closeImplied closes the open elements closed implicitly by the start tag,
+// including the foreign content broken out by the HTML start tags, e.g. p in svg.
+func (o *openElements) closeImplied(tag string) {
+	for breakoutTags[tag] && o.namespace() != "" {
+		o.truncate(len(o.tags) - 1)
+	}
+	o.truncate(len(o.tags) - closedByStart(o.tags, tag))
+}
+
+// follow makes the tokenizer recognize the CDATA sections only in the foreign content, as the parser does.
+// It is called before reading each token, so the tokenizer and the parser agree on where the tokens are.
+func (o *openElements) follow(z *html.Tokenizer) {
+	z.AllowCDATA(o.namespace() != "")
+}
+
+// followStart keeps the tokenizer out of the raw text after the start tag which the parser does not parse as raw text,
+// e.g. style in svg and the ignored title in select. It is called after closeImplied and before push.
+func (o *openElements) followStart(z *html.Tokenizer) {
+	if o.namespace() != "" || o.inSelect() {
+		z.NextIsNotRawText()
+	}
+}
+
+// inSelect reports whether the innermost open element is a select, or an option or optgroup in it.
+func (o *openElements) inSelect() bool {
+	for i := len(o.tags) - 1; i >= 0; i-- {
+		if o.tags[i] != "option" && o.tags[i] != "optgroup" {
+			return o.tags[i] == "select"
+		}
+	}
+	return false
+}
+
+// index returns the index of the innermost open element of the tag, or -1 if none.
+// The end tag closes it and those opened after it.
+func (o *openElements) index(tag string) int {
+	for i := len(o.tags) - 1; i >= 0; i-- {
+		if o.tags[i] == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// keepsOpen reports whether the start tag leaves its element open.
+// The self-closing flag is ignored by the HTML elements, except in the foreign content.
+func (o *openElements) keepsOpen(tag string, selfClosing bool) bool {
+	return !voidElements[tag] && (!selfClosing || o.namespace() == "")
+}
+
+// namespace returns the namespace of the foreign content which the open elements are in, or empty string if none.
+func (o *openElements) namespace() string {
+	for i := len(o.tags) - 1; i >= 0; i-- {
+		switch o.tags[i] {
+		case "svg", "math":
+			return o.tags[i]
+		case "foreignobject", "desc", "title", "mi", "mo", "mn", "ms", "mtext":
+			return ""
+		}
+	}
+	return ""
+}
+
+// breakoutTags is the tags of the HTML start tags which close the foreign content.
+// The font start tag breaks out only with the color, face or size attributes, which is not followed.
+var breakoutTags = map[string]bool{
+	"b": true, "big": true, "blockquote": true, "body": true, "br": true, "center": true, "code": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "em": true, "embed": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "head": true, "hr": true, "i": true, "img": true, "li": true, "listing": true, "menu": true,
+	"meta": true, "nobr": true, "ol": true, "p": true, "pre": true, "ruby": true, "s": true, "small": true,
+	"span": true, "strong": true, "strike": true, "sub": true, "sup": true, "table": true, "tt": true, "u": true,
+	"ul": true, "var": true,
+}
+
+// scopeTags is the tags of the open elements which stop searching for the elements closed implicitly.
+var scopeTags = map[string]bool{
+	"applet": true, "button": true, "caption": true, "html": true, "marquee": true, "math": true, "object": true,
+	"svg": true, "table": true, "td": true, "template": true, "th": true,
+}
+
+// closedByStart returns the number of the open elements closed implicitly by the start tag, as the common rules of the HTML syntax.
+func closedByStart(open []string, tag string) int {
+	n := len(open)
+	if pEndTagFollowers[tag] || tag == "li" || tag == "dt" || tag == "dd" {
+		if i := lastOpen(open, []string{"p"}, nil, true); i >= 0 {
+			open = open[:i]
+		}
+	}
+	i := -1
+	switch tag {
+	case "li":
+		i = lastOpen(open, []string{"li"}, []string{"ol", "ul"}, true)
+	case "dt", "dd":
+		i = lastOpen(open, []string{"dt", "dd"}, []string{"dl"}, true)
+	case "option":
+		i = lastOpen(open, []string{"option"}, []string{"datalist", "optgroup", "select"}, true)
+	case "optgroup":
+		i = lastOpen(open, []string{"optgroup", "option"}, []string{"datalist", "select"}, true)
+	case "tr":
+		i = lastOpen(open, []string{"tr"}, []string{"table"}, false)
+	case "td", "th":
+		i = lastOpen(open, []string{"td", "th"}, []string{"table", "tr"}, false)
+	case "thead", "tbody", "tfoot":
+		i = lastOpen(open, []string{"thead", "tbody", "tfoot"}, []string{"table"}, false)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if len(open) > 0 && containsString([]string{"h1", "h2", "h3", "h4", "h5", "h6"}, open[len(open)-1]) {
+			i = len(open) - 1
+		}
+	}
+	if i >= 0 {
+		open = open[:i]
+	}
+	return n - len(open)
+}
+
+// lastOpen returns the index of the innermost open element in targets, searching up to the stops,
+// and the scopeTags as well if scoped, or -1 if none.
+func lastOpen(open, targets, stops []string, scoped bool) int {
+	for i := len(open) - 1; i >= 0; i-- {
+		if containsString(targets, open[i]) {
+			return i
+		}
+		if containsString(stops, open[i]) || (scoped && scopeTags[open[i]]) {
+			return -1
+		}
+	}
+	return -1
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffLen is the number of bytes to prescan for the encoding declaration, as the HTML5 encoding sniffing algorithm.
+const sniffLen = 1024
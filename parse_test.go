@@ -0,0 +1,150 @@
+package supersimplesoup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"io"
+	"strings"
+	"testing"
+)
+
+func encode(t *testing.T, e encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := e.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseWithCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         []byte
+		contentType string
+		charset     string
+		title       string
+	}{
+		{"meta-charset", encode(t, simplifiedchinese.GBK, `<meta charset="gbk"><title>超简单</title>`), "", "gbk", "超简单"},
+		{"meta-http-equiv", encode(t, japanese.ShiftJIS, `<meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS"><title>日本語</title>`), "", "shift_jis", "日本語"},
+		{"content-type", encode(t, charmap.Windows1251, `<title>Привет</title>`), "text/html; charset=windows-1251", "windows-1251", "Привет"},
+		{"content-type-over-meta", encode(t, charmap.Windows1251, `<meta charset="utf-8"><title>Привет</title>`), "text/html; charset=windows-1251", "windows-1251", "Привет"},
+		{"latin-1", encode(t, charmap.ISO8859_1, `<meta charset="iso-8859-1"><title>café</title>`), "", "windows-1252", "café"},
+		{"bom", append([]byte("\xef\xbb\xbf"), `<meta charset="gbk"><title>简单</title>`...), "", "utf-8", "简单"},
+		{"meta-utf-16", []byte(`<meta charset="utf-16"><title>简单</title>`), "", "utf-8", "简单"},
+		{"default-utf-8", []byte(`<title>简单</title>`), "", "utf-8", "简单"},
+		{"default-windows-1252", encode(t, charmap.Windows1252, `<title>café</title>`), "", "windows-1252", "café"},
+		{"default-ascii-prefix", []byte(strings.Repeat(" ", 2048) + `<title>简单</title>`), "", "utf-8", "简单"},
+		{"meta-ascii-prefix", encode(t, charmap.Windows1252, `<meta charset="windows-1252">`+strings.Repeat(" ", 2048)+`<title>café</title>`), "", "windows-1252", "café"},
+	}
+
+	for _, test := range tests {
+		doc, got, err := ParseWithCharset(bytes.NewReader(test.src), test.contentType)
+		if err != nil {
+			t.Errorf("`%s` parse error, %v", test.name, err)
+			continue
+		}
+		if test.charset != got {
+			t.Errorf("`%s` charset, want %q, got %q", test.name, test.charset, got)
+		}
+		if got := doc.Query("title").Text(); test.title != got {
+			t.Errorf("`%s` title text, want %q, got %q", test.name, test.title, got)
+		}
+	}
+
+	for name, src := range map[string][]byte{
+		"utf-8":    []byte("\xef\xbb\xbf<p>x</p>"),
+		"utf-16le": encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "<p>x</p>"),
+		"utf-16be": encode(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), "<p>x</p>"),
+	} {
+		doc, got, err := ParseWithCharset(bytes.NewReader(src), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body := doc.Query("body"); got != name || body.FullText() != "x" || body.FirstChildNode().Data != "p" {
+			t.Errorf("`%s` bom, want %q and body text %q, got %q and %q", name, name, "x", got, body.FullText())
+		}
+	}
+
+	doc, got, _ := ParseWithOptions(context.Background(), bytes.NewReader(encode(t, simplifiedchinese.GBK, `<p>中文</p>`)), ParseOptions{DetectCharset: true, ContentType: "text/html; charset=gb2312"})
+	if got != "gbk" || doc.Query("p").Text() != "中文" {
+		t.Errorf("charset with options, want %q and %q, got %q and %q", "gbk", "中文", got, doc.Query("p").Text())
+	}
+	if _, got, _ := ParseWithOptions(context.Background(), strings.NewReader(`<p>x</p>`), ParseOptions{}); got != "" {
+		t.Errorf("charset without detection, want %q, got %q", "", got)
+	}
+}
+
+type slowReader struct {
+	cancel context.CancelFunc
+	reads  int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	r.reads++
+	if r.reads == 3 {
+		r.cancel()
+	}
+	return copy(p, "<div>"), nil
+}
+
+func TestParseWithOptionsLimits(t *testing.T) {
+	deep := strings.Repeat("<div>", 100) + "x" + strings.Repeat("</div>", 100)
+	tests := []struct {
+		name string
+		src  string
+		opts ParseOptions
+		want error
+	}{
+		{"no-limit", deep, ParseOptions{}, nil},
+		{"max-bytes", deep, ParseOptions{MaxBytes: 100}, ErrTooLarge},
+		{"max-bytes-exact", deep, ParseOptions{MaxBytes: int64(len(deep))}, nil},
+		{"max-depth", deep, ParseOptions{MaxDepth: 50}, ErrTooDeep},
+		{"max-depth-enough", deep, ParseOptions{MaxDepth: 101}, nil},
+		{"max-depth-exceeded", deep, ParseOptions{MaxDepth: 100}, ErrTooDeep},
+		{"max-nodes", deep, ParseOptions{MaxNodes: 100}, ErrTooManyNodes},
+		{"max-nodes-enough", deep, ParseOptions{MaxNodes: 101}, nil},
+		{"max-depth-implied-ends", "<ul>" + strings.Repeat("<li><p>x<div>y</div>", 100) + "</ul><table>" + strings.Repeat("<tr><td>1<td>2", 100) + "</table>", ParseOptions{MaxDepth: 5}, nil},
+		{"max-depth-self-closing", strings.Repeat("<div/>", 100), ParseOptions{MaxDepth: 50}, ErrTooDeep},
+		{"max-depth-foreign", "<svg>" + strings.Repeat("<g/>", 100) + "</svg>", ParseOptions{MaxDepth: 2}, nil},
+	}
+
+	for _, test := range tests {
+		_, _, err := ParseWithOptions(context.Background(), strings.NewReader(test.src), test.opts)
+		if !errors.Is(err, test.want) || (test.want == nil && err != nil) {
+			t.Errorf("`%s` parse error, want %v, got %v", test.name, test.want, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &slowReader{cancel: cancel}
+	if _, _, err := ParseWithOptions(ctx, io.LimitReader(r, 1<<30), ParseOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled parse error, want %v, got %v", context.Canceled, err)
+	}
+	if r.reads > 4 {
+		t.Errorf("canceled parse reads, want at most %d, got %d", 4, r.reads)
+	}
+
+	endless := []struct {
+		opts ParseOptions
+		want error
+	}{
+		{ParseOptions{MaxDepth: 1000}, ErrTooDeep},
+		{ParseOptions{MaxNodes: 1000}, ErrTooManyNodes},
+	}
+	for _, test := range endless {
+		r := &slowReader{cancel: func() {}}
+		if _, _, err := ParseWithOptions(context.Background(), io.LimitReader(r, 1<<30), test.opts); !errors.Is(err, test.want) {
+			t.Errorf("endless nested parse error, want %v, got %v", test.want, err)
+		}
+		if r.reads > 1001 {
+			t.Errorf("endless nested parse reads, want at most %d, got %d", 1001, r.reads)
+		}
+	}
+}
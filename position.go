@@ -0,0 +1,146 @@
+package supersimplesoup
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strconv"
+	"strings"
+	"weak"
+)
+
+// Position describes a location in the input HTML.
+//
+// For the input transcoded by the charset detection, the location is in the transcoded UTF-8 input.
+type Position struct {
+	Offset int // byte offset, starting at 0
+	Line   int // line number, starting at 1
+	Column int // column number in bytes, starting at 1
+}
+
+// IsValid reports whether the position is recorded.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position in the form of line:column, or - if the position is not recorded.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Position returns where the start tag of this element node appeared in the input.
+//
+// The position is only recorded by ParseWithOptions with RecordPositions, and it is kept while this node stays in its document.
+// It is not valid for the element nodes implied by the parser, e.g. the tbody of a table without it,
+// or cloned by the parser, e.g. the i reopened after </b> in <b><i></b></i>.
+func (n *Node) Position() Position {
+	return n.span().start
+}
+
+// EndPosition returns where the end tag of this element node appeared in the input.
+//
+// It is not valid if the position is not recorded or the end tag is omitted.
+func (n *Node) EndPosition() Position {
+	return n.span().end
+}
+
+func (n *Node) span() nodeSpan {
+	if n == nil {
+		return nodeSpan{}
+	}
+	if info := getDocumentInfo(rootNode(n)); info != nil {
+		return info.spans[weak.Make((*html.Node)(n))]
+	}
+	return nodeSpan{}
+}
+
+// DebugString returns a short description of this node for debugging, e.g. div#main.a.b at 4:2,
+// with its position if recorded. It is not String, so the formatting of a node by fmt is unchanged.
+func (n *Node) DebugString() string {
+	if n == nil {
+		return "<nil>"
+	}
+	var s string
+	switch n.Type {
+	case html.DocumentNode:
+		s = "#document"
+	case html.TextNode:
+		s = fmt.Sprintf("#text %q", n.Data)
+	case html.CommentNode:
+		s = fmt.Sprintf("#comment %q", n.Data)
+	case html.DoctypeNode:
+		s = "#doctype " + n.Data
+	default:
+		s = n.Data
+		if id := n.ID(); id != "" {
+			s += "#" + id
+		}
+		for _, class := range strings.Fields(n.Class()) {
+			s += "." + class
+		}
+	}
+	if pos := n.Position(); pos.IsValid() {
+		s += " at " + pos.String()
+	}
+	return s
+}
+
+// nodeSpan is the positions of the start and end tags of an element node.
+type nodeSpan struct {
+	start Position
+	end   Position
+}
+
+// positionAttr is the attribute marking the index of the recorded span on each start tag in the input stream,
+// so that the spans can be found on the element nodes after parsing, even if the parser moves them.
+const positionAttr = "data-supersimplesoup-position"
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"keygen": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// advance moves the position past the raw bytes of the current token.
+func (r *tokenReader) advance() {
+	for _, c := range r.buf {
+		r.pos.Offset++
+		if c == '\n' {
+			r.pos.Line++
+			r.pos.Column = 1
+		} else {
+			r.pos.Column++
+		}
+	}
+}
+
+// mark inserts positionAttr with the id after the tag name of the current start tag.
+func (r *tokenReader) mark(nameLen, id int) {
+	k := 1 + nameLen
+	mark := ` ` + positionAttr + `="` + strconv.Itoa(id) + `"`
+	buf := make([]byte, 0, len(r.buf)+len(mark))
+	buf = append(append(append(buf, r.buf[:k]...), mark...), r.buf[k:]...)
+	r.buf = buf
+}
+
+// applyPositions removes the marks from the element nodes of the tree, and records their spans for the document.
+// The element nodes cloned by the parser share the mark, and only the first one in document order gets the span.
+func (r *tokenReader) applyPositions(doc *Node) {
+	info := &documentInfo{spans: make(map[weak.Pointer[html.Node]]nodeSpan)}
+	seen := make([]bool, len(r.spans))
+	for node := range doc.Elements() {
+		for i, attr := range node.Attr {
+			if attr.Key != positionAttr {
+				continue
+			}
+			node.Attr = append(node.Attr[:i:i], node.Attr[i+1:]...)
+			if id, err := strconv.Atoi(attr.Val); err == nil && id >= 0 && id < len(r.spans) && !seen[id] {
+				seen[id] = true
+				info.spans[weak.Make((*html.Node)(node))] = r.spans[id]
+			}
+			break
+		}
+	}
+	setDocumentInfo(doc, info)
+}
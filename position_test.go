@@ -0,0 +1,121 @@
+package supersimplesoup
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const testPositionHTML = `<!DOCTYPE html>
+<html>
+<body>
+	<div id="main" class="a b">
+		<P>text<br>more</p>
+		<table><tr><td>cell</td></tr></table>
+		<b>1<i>2</b>3</i>
+	</div>
+</body>
+</html>`
+
+func TestPosition(t *testing.T) {
+	doc, _, err := ParseWithOptions(context.Background(), strings.NewReader(testPositionHTML), ParseOptions{RecordPositions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tag   string
+		start string
+		end   string
+	}{
+		{"html", "2:1", "10:1"},
+		{"div", "4:2", "8:2"},
+		{"p", "5:3", "5:18"},
+		{"br", "5:10", "-"},
+		{"tbody", "-", "-"},
+		{"td", "6:14", "6:22"},
+		{"i", "7:7", "-"},
+	}
+
+	for _, test := range tests {
+		node := doc.Query(test.tag)
+		if got := node.Position().String(); test.start != got {
+			t.Errorf("`%s` start position, want %s, got %s", test.tag, test.start, got)
+		}
+		if got := node.EndPosition().String(); test.end != got {
+			t.Errorf("`%s` end position, want %s, got %s", test.tag, test.end, got)
+		}
+	}
+
+	div := doc.Query("div")
+	if want, got := 31, div.Position().Offset; want != got {
+		t.Errorf("`div` start offset, want %d, got %d", want, got)
+	}
+	if !strings.HasPrefix(testPositionHTML[div.Position().Offset:], "<div") {
+		t.Errorf("`div` start offset does not point to the start tag")
+	}
+	if clone := doc.QueryAll("i"); len(clone) != 2 || clone[1].Position().IsValid() {
+		t.Errorf("`i` cloned by the parser, want 2 nodes and the second without position, got %d", len(clone))
+	}
+	if _, ok := div.Attributes()[positionAttr]; ok || len(div.Attr) != 2 {
+		t.Errorf("`div` attributes still have position mark, %v", div.Attr)
+	}
+	if strings.Contains(doc.HTML(), positionAttr) {
+		t.Errorf("html still has position mark")
+	}
+
+	_, err = div.Find("span")
+	if want := "not found element `span` in `div` at 4:2"; err == nil || err.Error() != want {
+		t.Errorf("find error, want %q, got %v", want, err)
+	}
+	if want, got := "div#main.a.b at 4:2", div.DebugString(); want != got {
+		t.Errorf("debug string, want %q, got %q", want, got)
+	}
+	if want, got := `#text "text"`, doc.Query("p").FirstChildNode().DebugString(); want != got {
+		t.Errorf("text node debug string, want %q, got %q", want, got)
+	}
+	if got := root.Query("div").Position(); got.IsValid() {
+		t.Errorf("position without recording, want invalid, got %s", got)
+	}
+}
+
+func TestTokenReaderMarks(t *testing.T) {
+	b, err := io.ReadAll(newTokenReader(strings.NewReader(testPositionHTML), ParseOptions{RecordPositions: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(` ` + positionAttr + `="\d+"`)
+	if want, got := 10, len(re.FindAll(b, -1)); want != got {
+		t.Errorf("position mark count, want %d, got %d", want, got)
+	}
+	// The tag names are lowercased by the tokenizer.
+	if want, got := strings.Replace(testPositionHTML, "<P>", "<p>", 1), re.ReplaceAllString(string(b), ""); want != got {
+		t.Errorf("unmarked input, want %q, got %q", want, got)
+	}
+}
+
+func TestPositionForeignContent(t *testing.T) {
+	tests := []string{
+		`<svg><![CDATA[x>]]<b>]]></svg>`,
+		`<p>x<![CDATA[<b>]]></p>`,
+		`<svg><style><b>x</b></style><title><i>t</i></title></svg>`,
+		`<svg><foreignObject><style><b>x</b></style></foreignObject></svg>`,
+		`<math><mi><style><b>x</b></style></mi></math>`,
+		`<svg><p><style><b>x</b></style></p>`,
+		`<select><title><b>x</b></title><option>o</select>`,
+	}
+	for _, test := range tests {
+		want, _ := Parse(strings.NewReader(test))
+		doc, _, err := ParseWithOptions(context.Background(), strings.NewReader(test), ParseOptions{RecordPositions: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.HTML() != want.HTML() {
+			t.Errorf("`%s` html with positions, want %s, got %s", test, want.HTML(), doc.HTML())
+		}
+		if b := doc.Query("b"); b != nil && !b.Position().IsValid() {
+			t.Errorf("`%s` position of b, want valid, got %s", test, b.Position())
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package supersimplesoup
+
+import (
+	"bufio"
+	"bytes"
+	"golang.org/x/net/html"
+	"io"
+	"strings"
+)
+
+// RenderOptions controls how Render writes the HTML.
+type RenderOptions struct {
+	// Indent makes the block element nodes start new lines indented by it for each level of depth.
+	Indent string
+	// Minify drops the comments, the insignificant white space and the optional tags. Indent is ignored if set.
+	Minify bool
+}
+
+// Render writes the HTML of this node to w with the specified options.
+//
+// The contents of pre, textarea, script, style and the foreign element nodes like svg are written as they are,
+// and so are the element nodes holding text or inline element nodes when indenting.
+func (n *Node) Render(w io.Writer, opts RenderOptions) error {
+	if n == nil {
+		return nil
+	}
+	if opts.Indent == "" && !opts.Minify {
+		return html.Render(w, (*html.Node)(n))
+	}
+	r := &renderer{w: bufio.NewWriter(w), opts: opts}
+	if opts.Minify {
+		r.minify(n)
+	} else {
+		r.pretty(n, 0)
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return r.w.Flush()
+}
+
+// PrettyHTML returns the HTML of this node where the block element nodes are indented by indent, as Render.
+func (n *Node) PrettyHTML(indent string) string {
+	var buf bytes.Buffer
+	n.Render(&buf, RenderOptions{Indent: indent})
+	return buf.String()
+}
+
+// MinifiedHTML returns the HTML of this node without the comments, the insignificant white space and the optional tags, as Render.
+func (n *Node) MinifiedHTML() string {
+	var buf bytes.Buffer
+	n.Render(&buf, RenderOptions{Minify: true})
+	return buf.String()
+}
+
+// verbatimTags is the tags of the element nodes whose contents are rendered as they are.
+var verbatimTags = map[string]bool{
+	"iframe": true, "listing": true, "noembed": true, "noframes": true, "noscript": true, "plaintext": true,
+	"pre": true, "script": true, "style": true, "textarea": true, "xmp": true,
+}
+
+// layoutBlockTags is the tags of the element nodes around which the white space is insignificant, besides displayBlockTags.
+var layoutBlockTags = map[string]bool{
+	"base": true, "body": true, "caption": true, "col": true, "colgroup": true, "head": true, "html": true,
+	"link": true, "meta": true, "optgroup": true, "option": true, "style": true, "tbody": true, "td": true,
+	"tfoot": true, "th": true, "thead": true, "title": true, "tr": true,
+}
+
+func isLayoutBlock(n *Node) bool {
+	if n == nil || n.Type == html.DoctypeNode {
+		return true
+	}
+	return n.IsElementNode() && n.Namespace == "" && (displayBlockTags[n.Data] || layoutBlockTags[n.Data])
+}
+
+func isVerbatim(n *Node) bool {
+	return n.IsElementNode() && (n.Namespace != "" || verbatimTags[n.Data])
+}
+
+func isBlank(n *Node) bool {
+	return n.IsTextNode() && strings.TrimSpace(n.Data) == ""
+}
+
+type renderer struct {
+	w    *bufio.Writer
+	opts RenderOptions
+	err  error
+	line bool // something is written on the current line
+}
+
+func (r *renderer) write(s string) {
+	if r.err == nil {
+		_, r.err = r.w.WriteString(s)
+		r.line = true
+	}
+}
+
+// raw writes the node as html.Render.
+func (r *renderer) raw(n *Node) {
+	if r.err == nil {
+		r.err = html.Render(r.w, (*html.Node)(n))
+		r.line = true
+	}
+}
+
+func (r *renderer) startTag(n *Node) {
+	r.write("<" + n.Data)
+	for _, attr := range n.Attr {
+		key := attr.Key
+		if attr.Namespace != "" {
+			key = attr.Namespace + ":" + key
+		}
+		r.write(" " + key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	r.write(">")
+}
+
+func (r *renderer) newline(depth int) {
+	if r.line {
+		r.write("\n" + strings.Repeat(r.opts.Indent, depth))
+	} else {
+		r.write(strings.Repeat(r.opts.Indent, depth))
+	}
+}
+
+// isBlockContent reports whether all the children of the node are block element nodes, comments or blank text nodes.
+func isBlockContent(n *Node) bool {
+	for c := range n.Children() {
+		switch c.Type {
+		case html.CommentNode, html.DoctypeNode:
+		case html.TextNode:
+			if !isBlank(c) {
+				return false
+			}
+		default:
+			if !isLayoutBlock(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *renderer) pretty(n *Node, depth int) {
+	switch {
+	case n.Type == html.DocumentNode:
+		for c := range n.Children() {
+			if !isBlank(c) {
+				r.newline(depth)
+				r.pretty(c, depth)
+			}
+		}
+	case !n.IsElementNode() || isVerbatim(n) || voidElements[n.Data] || !isBlockContent(n):
+		r.raw(n)
+	case n.FirstChild == nil:
+		r.raw(n)
+	default:
+		r.startTag(n)
+		for c := range n.Children() {
+			if !isBlank(c) {
+				r.newline(depth + 1)
+				r.pretty(c, depth+1)
+			}
+		}
+		r.newline(depth)
+		r.write("</" + n.Data + ">")
+	}
+}
+
+// nextSignificant returns the next sibling which is not dropped by minifying, or nil if none.
+func nextSignificant(n *Node) *Node {
+	for s := n.NextSiblingNode(); s != nil; s = s.NextSiblingNode() {
+		if s.Type == html.CommentNode || s.IsTextNode() && minifiedText(s) == "" {
+			continue
+		}
+		return s
+	}
+	return nil
+}
+
+// prevSignificant returns the previous sibling which is not a comment, or nil if none.
+func prevSignificant(n *Node) *Node {
+	for s := n.PrevSiblingNode(); s != nil; s = s.PrevSiblingNode() {
+		if s.Type != html.CommentNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// nextElementOrText returns the next sibling which is not a comment, or nil if none.
+func nextElementOrText(n *Node) *Node {
+	for s := n.NextSiblingNode(); s != nil; s = s.NextSiblingNode() {
+		if s.Type != html.CommentNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// pEndTagFollowers is the tags of the element nodes which make the end tag of the preceding p element node optional.
+var pEndTagFollowers = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true, "dialog": true, "div": true,
+	"dl": true, "fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hgroup": true, "hr": true, "main": true, "menu": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "search": true, "section": true, "table": true, "ul": true,
+}
+
+// omitStartTag reports whether the start tag of the element node is optional, as the HTML syntax.
+func omitStartTag(n *Node) bool {
+	if len(n.Attr) > 0 {
+		return false
+	}
+	first := n.FirstChildNode()
+	for first != nil && (first.Type == html.CommentNode || isBlank(first)) {
+		first = first.NextSiblingNode()
+	}
+	switch n.Data {
+	case "html":
+		return true
+	case "tbody":
+		// The tr element node after the omitted end tag of thead or tbody would belong to it.
+		prev := prevSignificant(n)
+		return first != nil && first.IsElementNode() && first.Data == "tr" &&
+			(prev == nil || !prev.IsElementNode() || (prev.Data != "thead" && prev.Data != "tbody" && prev.Data != "tfoot"))
+	case "head":
+		return first == nil || first.IsElementNode()
+	case "body":
+		if first == nil {
+			return true
+		}
+		if first.IsElementNode() {
+			switch first.Data {
+			case "meta", "link", "script", "style", "template":
+				return false
+			}
+			return true
+		}
+		// The leading white space of the text is dropped by minifying.
+		return first.IsTextNode()
+	}
+	return false
+}
+
+// omitEndTag reports whether the end tag of the element node is optional, as the HTML syntax.
+func omitEndTag(n *Node) bool {
+	next := nextSignificant(n)
+	is := func(tags ...string) bool {
+		return next != nil && next.IsElementNode() && containsString(tags, next.Data)
+	}
+	switch n.Data {
+	case "html", "head", "body":
+		return true
+	case "li":
+		return next == nil || is("li")
+	case "dt":
+		return is("dt", "dd")
+	case "dd":
+		return next == nil || is("dt", "dd")
+	case "p":
+		if next == nil {
+			parent := n.ParentNode()
+			return parent == nil || !containsString([]string{"a", "audio", "del", "ins", "map", "noscript", "video"}, parent.Data)
+		}
+		return next.IsElementNode() && pEndTagFollowers[next.Data]
+	case "option":
+		return next == nil || is("option", "optgroup")
+	case "optgroup":
+		return next == nil || is("optgroup")
+	case "tr":
+		return next == nil || is("tr")
+	case "td", "th":
+		return next == nil || is("td", "th")
+	case "thead":
+		return is("tbody", "tfoot")
+	case "tbody":
+		return next == nil || is("tbody", "tfoot")
+	case "tfoot":
+		return next == nil
+	}
+	return false
+}
+
+func (r *renderer) minify(n *Node) {
+	switch n.Type {
+	case html.CommentNode:
+		return
+	case html.TextNode:
+		if s := minifiedText(n); s != "" {
+			r.write(html.EscapeString(s))
+		}
+		return
+	case html.DocumentNode:
+		for c := range n.Children() {
+			r.minify(c)
+		}
+		return
+	case html.ElementNode:
+	default:
+		r.raw(n)
+		return
+	}
+	if isVerbatim(n) {
+		r.raw(n)
+		return
+	}
+	if n.Namespace == "" && omitStartTag(n) {
+		// The start tag is omitted.
+	} else {
+		r.startTag(n)
+	}
+	if voidElements[n.Data] {
+		return
+	}
+	for c := range n.Children() {
+		r.minify(c)
+	}
+	if n.Namespace != "" || !omitEndTag(n) {
+		r.write("</" + n.Data + ">")
+	}
+}
+
+// minifiedText returns the text of the text node with the white space collapsed,
+// dropping the white space next to the block element nodes.
+func minifiedText(n *Node) string {
+	prev, next := prevSignificant(n), nextElementOrText(n)
+	parentBlock := n.Parent == nil || n.Parent.Type == html.DocumentNode || isLayoutBlock(n.ParentNode())
+	s := n.Data
+	if (prev == nil && parentBlock) || (prev != nil && isLayoutBlock(prev)) {
+		s = strings.TrimLeft(s, " \t\n\r\f")
+	}
+	if (next == nil && parentBlock) || (next != nil && isLayoutBlock(next)) {
+		s = strings.TrimRight(s, " \t\n\r\f")
+	}
+	var buf strings.Builder
+	space := false
+	for _, c := range s {
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' {
+			space = true
+			continue
+		}
+		if space {
+			buf.WriteByte(' ')
+			space = false
+		}
+		buf.WriteRune(c)
+	}
+	if space {
+		buf.WriteByte(' ')
+	}
+	return buf.String()
+}
@@ -0,0 +1,132 @@
+package supersimplesoup
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testRenderHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<!-- comment -->
+	<title>Render  test</title>
+	<style>p  {  color: red }</style>
+</head>
+<body>
+	<div class="a"><p>Hello,   <b>big</b>
+		world!</p>
+		<pre>  keep
+    this  </pre><form><textarea>
+ raw  </textarea></form>
+	<ul><li>one</li>  <li>two <i>2</i> </li></ul></div>
+	<table><tr><td>1</td><td>2</td></tr></table>
+	<p>a &amp; b <br> c</p>
+</body>
+</html>`
+
+func TestPrettyHTML(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testRenderHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <!-- comment -->
+    <title>Render  test</title>
+    <style>p  {  color: red }</style>
+  </head>
+  <body>
+    <div class="a">
+      <p>Hello,   <b>big</b>
+		world!</p>
+      <pre>  keep
+    this  </pre>
+      <form><textarea> raw  </textarea></form>
+      <ul>
+        <li>one</li>
+        <li>two <i>2</i> </li>
+      </ul>
+    </div>
+    <table>
+      <tbody>
+        <tr>
+          <td>1</td>
+          <td>2</td>
+        </tr>
+      </tbody>
+    </table>
+    <p>a &amp; b <br/> c</p>
+  </body>
+</html>`
+	if got := doc.PrettyHTML("  "); got != want {
+		t.Errorf("pretty html, want\n%s\ngot\n%s", want, got)
+	}
+	pretty, err := Parse(strings.NewReader(doc.PrettyHTML("\t")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pretty.InnerText(), doc.InnerText(); got != want {
+		t.Errorf("pretty html inner text, want %q, got %q", want, got)
+	}
+}
+
+func TestMinifiedHTML(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testRenderHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<!DOCTYPE html><html lang="en"><title>Render test</title><style>p  {  color: red }</style><div class="a"><p>Hello, <b>big</b> world!<pre>  keep
+    this  </pre><form><textarea> raw  </textarea></form><ul><li>one<li>two <i>2</i></ul></div><table><tr><td>1<td>2</table><p>a &amp; b <br> c`
+	got := doc.MinifiedHTML()
+	if got != want {
+		t.Errorf("minified html, want\n%s\ngot\n%s", want, got)
+	}
+	minified, err := Parse(strings.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again := minified.MinifiedHTML(); again != got {
+		t.Errorf("minified html of minified html, want\n%s\ngot\n%s", got, again)
+	}
+
+	tests := []struct {
+		html string
+		want string
+	}{
+		{"<dl><dt>a</dt><dd>b</dd><dt>c</dt></dl>", "<dl><dt>a<dd>b<dt>c</dt></dl>"},
+		{"<a href=\"#\"><p>x</p></a>", "<a href=\"#\"><p>x</p></a>"},
+		{"<p>x</p><span>y</span>", "<p>x</p><span>y</span>"},
+		{"<select><option>a</option><optgroup label=\"g\"><option>b</option></optgroup></select>", "<select><option>a<optgroup label=\"g\"><option>b</select>"},
+		{"<svg><circle r=\"1\"></circle></svg>", "<svg><circle r=\"1\"></circle></svg>"},
+		{"<span> a </span> <span>b</span>", "<span> a </span> <span>b</span>"},
+	}
+	for _, test := range tests {
+		if got := parseBody(t, test.html).MinifiedHTML(); got != test.want {
+			t.Errorf("`%s` minified html, want %s, got %s", test.html, test.want, got)
+		}
+	}
+}
+
+type errWriter struct{}
+
+var errWrite = errors.New("write error")
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestRender(t *testing.T) {
+	n := parseBody(t, "<div><p>x</p></div>").Query("div")
+	var buf bytes.Buffer
+	if err := n.Render(&buf, RenderOptions{}); err != nil || buf.String() != n.HTML() {
+		t.Errorf("render, want %s, got %s %v", n.HTML(), buf.String(), err)
+	}
+	for _, opts := range []RenderOptions{{}, {Indent: " "}, {Minify: true}} {
+		if err := n.Render(errWriter{}, opts); !errors.Is(err, errWrite) {
+			t.Errorf("render %+v to error writer, want %v, got %v", opts, errWrite, err)
+		}
+	}
+}
@@ -0,0 +1,786 @@
+package supersimplesoup
+
+import (
+	"container/list"
+	"fmt"
+	"golang.org/x/net/html"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Selector is a compiled CSS selector, which is safe for concurrent use by multiple goroutines.
+type Selector struct {
+	src string
+	sel cssSelector
+}
+
+// SelectorError describes a CSS selector which failed to compile.
+type SelectorError struct {
+	Selector string // the selector source
+	Offset   int    // byte offset in the selector source where the error occurred
+	Msg      string // description of the error
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("invalid selector `%s` at offset %d: %s", e.Selector, e.Offset, e.Msg)
+}
+
+// Compile parses a CSS selector and returns, if successful, a Selector that can be used to match against nodes.
+//
+// It returns a *SelectorError if the selector is invalid.
+func Compile(sel string) (*Selector, error) {
+	s, err := parseSelector(sel)
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{src: sel, sel: s}, nil
+}
+
+// selectorCacheSize is the max number of the selectors cached by compileCached.
+const selectorCacheSize = 256
+
+// selectorCache is the least recently used selectors compiled by compileCached, keyed by the selector source.
+var selectorCache = struct {
+	sync.Mutex
+	list  *list.List // the *Selector values, the most recently used at the front
+	elems map[string]*list.Element
+}{list: list.New(), elems: map[string]*list.Element{}}
+
+// compileCached is like Compile but reuses the selector recently compiled from the same source.
+// Only the selectorCacheSize recently used selectors are kept, so the selectors built dynamically do not grow it without bound.
+func compileCached(sel string) (*Selector, error) {
+	c := &selectorCache
+	c.Lock()
+	if e, ok := c.elems[sel]; ok {
+		c.list.MoveToFront(e)
+		c.Unlock()
+		return e.Value.(*Selector), nil
+	}
+	c.Unlock()
+	s, err := Compile(sel)
+	if err != nil {
+		return nil, err
+	}
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.elems[sel]; !ok {
+		c.elems[sel] = c.list.PushFront(s)
+		if c.list.Len() > selectorCacheSize {
+			delete(c.elems, c.list.Remove(c.list.Back()).(*Selector).src)
+		}
+	}
+	return s, nil
+}
+
+// mustCompileCached is like compileCached but panics if the selector cannot be parsed.
+func mustCompileCached(sel string) *Selector {
+	s, err := compileCached(sel)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// MustCompile is like Compile but panics if the selector cannot be parsed.
+//
+// It simplifies safe initialization of global variables holding compiled selectors.
+func MustCompile(sel string) *Selector {
+	s, err := Compile(sel)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the source text used to compile the selector.
+func (s *Selector) String() string {
+	return s.src
+}
+
+// Match reports whether the node is an element node matched by the selector.
+func (s *Selector) Match(n *Node) bool {
+	return n != nil && s.sel.match(n)
+}
+
+// First returns the first child element node of n matched by the selector.
+//
+// It returns nil if no child element node is matched.
+func (s *Selector) First(n *Node) *Node {
+	if n == nil {
+		return nil
+	}
+	if ns := selectNodes(n, s.sel, 1); len(ns) > 0 {
+		return ns[0]
+	} else {
+		return nil
+	}
+}
+
+// All returns the child element nodes of n matched by the selector.
+//
+// It returns nil if no child element node is matched.
+func (s *Selector) All(n *Node) Nodes {
+	if n == nil {
+		return nil
+	}
+	return selectNodes(n, s.sel, 0)
+}
+
+// Select returns the first child element node matched by the specified CSS selector of this node.
+//
+// It returns nil if no child element node is matched, and panics if the selector is invalid, as Query.
+// The recently used selectors are cached, Compile the selector once for the hot paths with many selectors.
+//
+// Allow chaining call.
+func (n *Node) Select(sel string) *Node {
+	return mustCompileCached(sel).First(n)
+}
+
+// SelectAll returns the child element nodes matched by the specified CSS selector of this node.
+//
+// It returns nil if no child element node is matched, and panics if the selector is invalid, as QueryAll.
+//
+// Allow chaining call.
+func (n *Node) SelectAll(sel string) Nodes {
+	return mustCompileCached(sel).All(n)
+}
+
+// Select returns all the first child element node matched by the specified CSS selector on each node of this nodes.
+//
+// It returns nil if no child element node is matched, and panics if the selector is invalid, as Query.
+//
+// Allow chaining call.
+func (ns Nodes) Select(sel string) (found Nodes) {
+	if ns == nil {
+		return
+	}
+	s := mustCompileCached(sel)
+	for _, n := range ns {
+		if node := s.First(n); node != nil {
+			found = append(found, node)
+		}
+	}
+	return
+}
+
+// SelectAll returns all the child element nodes matched by the specified CSS selector on each node of this nodes.
+//
+// It returns nil if no child element node is matched, and panics if the selector is invalid, as QueryAll.
+//
+// Allow chaining call.
+func (ns Nodes) SelectAll(sel string) (found Nodes) {
+	if ns == nil {
+		return
+	}
+	s := mustCompileCached(sel)
+	for _, n := range ns {
+		found = append(found, s.All(n)...)
+	}
+	return
+}
+
+func selectNodes(n *Node, s cssSelector, m int) Nodes {
+	return queryFunc(n, s.match, m)
+}
+
+// cssSelector is a parsed CSS selector which reports whether an element node matches it.
+type cssSelector interface {
+	match(n *Node) bool
+}
+
+// cssList is a selector list, e.g. `a, b`.
+type cssList []cssSelector
+
+func (s cssList) match(n *Node) bool {
+	for _, sel := range s {
+		if sel.match(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// cssCompound is a compound selector, e.g. `a#id.class[attr]:first-child`.
+type cssCompound struct {
+	tag     string
+	filters []cssSelector
+}
+
+func (s *cssCompound) match(n *Node) bool {
+	if !n.IsElementNode() {
+		return false
+	}
+	if s.tag != "" && s.tag != n.Data {
+		return false
+	}
+	for _, f := range s.filters {
+		if !f.match(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// cssCombined is two selectors joined by a combinator, e.g. `a > b`.
+type cssCombined struct {
+	left       cssSelector
+	combinator byte
+	right      cssSelector
+}
+
+func (s *cssCombined) match(n *Node) bool {
+	return s.matchWithin(n, nil)
+}
+
+// matchWithin is like match but the left selectors are only matched by the descendants of scope, unless scope is nil.
+func (s *cssCombined) matchWithin(n, scope *Node) bool {
+	if !s.right.match(n) {
+		return false
+	}
+	switch s.combinator {
+	case ' ':
+		for p := n.ParentNode(); p != nil && p != scope; p = p.ParentNode() {
+			if matchWithin(s.left, p, scope) {
+				return true
+			}
+		}
+	case '>':
+		if p := n.ParentNode(); p != nil && p != scope {
+			return matchWithin(s.left, p, scope)
+		}
+	case '+':
+		if p := prevElementSibling(n); p != nil {
+			return matchWithin(s.left, p, scope)
+		}
+	case '~':
+		for p := prevElementSibling(n); p != nil; p = prevElementSibling(p) {
+			if matchWithin(s.left, p, scope) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchWithin reports whether the node is matched by the selector whose combined parts are all matched by the descendants of scope.
+func matchWithin(sel cssSelector, n, scope *Node) bool {
+	switch s := sel.(type) {
+	case cssList:
+		for _, sel := range s {
+			if matchWithin(sel, n, scope) {
+				return true
+			}
+		}
+		return false
+	case *cssCombined:
+		return s.matchWithin(n, scope)
+	}
+	return sel.match(n)
+}
+
+// cssAttr is an attribute selector, e.g. `[attr^=val i]`. The id and class selectors are represented by it as well.
+type cssAttr struct {
+	key  string
+	op   string
+	val  string
+	fold bool
+}
+
+func (s *cssAttr) match(n *Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == s.key {
+			return matchAttrValue(s.op, attr.Val, s.val, s.fold)
+		}
+	}
+	return false
+}
+
+func matchAttrValue(op, got, want string, fold bool) bool {
+	if fold {
+		got, want = strings.ToLower(got), strings.ToLower(want)
+	}
+	switch op {
+	case "":
+		return true
+	case "=":
+		return got == want
+	case "~=":
+		for _, f := range strings.Fields(got) {
+			if f == want {
+				return true
+			}
+		}
+		return false
+	case "|=":
+		return got == want || strings.HasPrefix(got, want+"-")
+	case "^=":
+		return want != "" && strings.HasPrefix(got, want)
+	case "$=":
+		return want != "" && strings.HasSuffix(got, want)
+	case "*=":
+		return want != "" && strings.Contains(got, want)
+	}
+	return false
+}
+
+// cssNth is the :nth-child family pseudo-class, matching the element whose position is an+b.
+type cssNth struct {
+	a, b   int
+	last   bool
+	ofType bool
+}
+
+func (s *cssNth) match(n *Node) bool {
+	if n.Parent == nil {
+		return false
+	}
+	i := 1
+	next := prevElementSibling
+	if s.last {
+		next = nextElementSibling
+	}
+	for c := next(n); c != nil; c = next(c) {
+		if !s.ofType || c.Data == n.Data {
+			i++
+		}
+	}
+	if s.a == 0 {
+		return i == s.b
+	}
+	return (i-s.b)/s.a >= 0 && (i-s.b)%s.a == 0
+}
+
+// cssOnly is the :only-child and :only-of-type pseudo-class.
+type cssOnly struct {
+	ofType bool
+}
+
+func (s *cssOnly) match(n *Node) bool {
+	if n.Parent == nil {
+		return false
+	}
+	for _, next := range []func(*Node) *Node{prevElementSibling, nextElementSibling} {
+		for c := next(n); c != nil; c = next(c) {
+			if !s.ofType || c.Data == n.Data {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cssNot is the :not() pseudo-class.
+type cssNot struct {
+	sel cssSelector
+}
+
+func (s *cssNot) match(n *Node) bool {
+	return !s.sel.match(n)
+}
+
+// cssHas is the :has() pseudo-class, matching the element which has a descendant matched by sel,
+// where sel is relative to the element, so all its parts are matched by the descendants.
+type cssHas struct {
+	sel cssSelector
+}
+
+func (s *cssHas) match(n *Node) bool {
+	return len(queryFunc(n, func(d *Node) bool { return matchWithin(s.sel, d, n) }, 1)) > 0
+}
+
+// cssFunc is a pseudo-class which needs no argument, e.g. `:empty`.
+type cssFunc func(n *Node) bool
+
+func (s cssFunc) match(n *Node) bool {
+	return s(n)
+}
+
+func prevElementSibling(n *Node) *Node {
+	for c := n.PrevSiblingNode(); c != nil; c = c.PrevSiblingNode() {
+		if c.IsElementNode() {
+			return c
+		}
+	}
+	return nil
+}
+
+func nextElementSibling(n *Node) *Node {
+	for c := n.NextSiblingNode(); c != nil; c = c.NextSiblingNode() {
+		if c.IsElementNode() {
+			return c
+		}
+	}
+	return nil
+}
+
+var cssPseudoClasses = map[string]cssSelector{
+	"first-child":   &cssNth{b: 1},
+	"last-child":    &cssNth{b: 1, last: true},
+	"only-child":    &cssOnly{},
+	"first-of-type": &cssNth{b: 1, ofType: true},
+	"last-of-type":  &cssNth{b: 1, last: true, ofType: true},
+	"only-of-type":  &cssOnly{ofType: true},
+	"empty": cssFunc(func(n *Node) bool {
+		for c := n.FirstChildNode(); c != nil; c = c.NextSiblingNode() {
+			if c.IsElementNode() || c.IsTextNode() {
+				return false
+			}
+		}
+		return true
+	}),
+	"root": cssFunc(func(n *Node) bool {
+		return n.Parent != nil && n.Parent.Type == html.DocumentNode
+	}),
+}
+
+type selectorParser struct {
+	src string
+	pos int
+}
+
+func parseSelector(src string) (cssSelector, error) {
+	p := &selectorParser{src: src}
+	p.skipSpace()
+	s, err := p.parseSelectorList()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return s, nil
+}
+
+func (p *selectorParser) errorf(format string, args ...any) error {
+	return &SelectorError{Selector: p.src, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n\f", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) consume(c byte) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *selectorParser) parseSelectorList() (cssSelector, error) {
+	var list cssList
+	for {
+		s, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+		if !p.consume(',') {
+			break
+		}
+		p.skipSpace()
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *selectorParser) parseComplex() (cssSelector, error) {
+	s, err := p.parseCompound()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		space := p.skipSpace()
+		if p.pos >= len(p.src) {
+			return s, nil
+		}
+		c := p.src[p.pos]
+		switch c {
+		case ',', ')':
+			return s, nil
+		case '>', '+', '~':
+			p.pos++
+			p.skipSpace()
+		default:
+			if !space {
+				return nil, p.errorf("unexpected %q", c)
+			}
+			c = ' '
+		}
+		right, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		s = &cssCombined{left: s, combinator: c, right: right}
+	}
+}
+
+func (p *selectorParser) parseCompound() (cssSelector, error) {
+	s := &cssCompound{}
+	start := p.pos
+	if !p.consume('*') && p.isNameStart() {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		s.tag = strings.ToLower(name)
+	}
+	for p.pos < len(p.src) {
+		var f cssSelector
+		var err error
+		switch p.src[p.pos] {
+		case '#':
+			p.pos++
+			var id string
+			if id, err = p.parseName(); err == nil {
+				f = &cssAttr{key: "id", op: "=", val: id}
+			}
+		case '.':
+			p.pos++
+			var class string
+			if class, err = p.parseName(); err == nil {
+				f = &cssAttr{key: "class", op: "~=", val: class}
+			}
+		case '[':
+			p.pos++
+			f, err = p.parseAttr()
+		case ':':
+			p.pos++
+			f, err = p.parsePseudo()
+		default:
+			if p.pos == start {
+				return nil, p.errorf("expected selector")
+			}
+			return s, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.filters = append(s.filters, f)
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected selector")
+	}
+	return s, nil
+}
+
+func (p *selectorParser) parseAttr() (cssSelector, error) {
+	p.skipSpace()
+	key, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	s := &cssAttr{key: strings.ToLower(key)}
+	p.skipSpace()
+	if p.consume(']') {
+		return s, nil
+	}
+	for _, op := range []string{"=", "~=", "|=", "^=", "$=", "*="} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			s.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if s.op == "" {
+		return nil, p.errorf("expected attribute operator")
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) && (p.src[p.pos] == '"' || p.src[p.pos] == '\'') {
+		s.val, err = p.parseString()
+	} else {
+		s.val, err = p.parseName()
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.consume('i') || p.consume('I') {
+		s.fold = true
+		p.skipSpace()
+	} else if p.consume('s') || p.consume('S') {
+		p.skipSpace()
+	}
+	if !p.consume(']') {
+		return nil, p.errorf("expected ']'")
+	}
+	return s, nil
+}
+
+func (p *selectorParser) parsePseudo() (cssSelector, error) {
+	start := p.pos
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	name = strings.ToLower(name)
+	if !p.consume('(') {
+		if s, ok := cssPseudoClasses[name]; ok {
+			return s, nil
+		}
+		p.pos = start
+		return nil, p.errorf("unsupported pseudo-class :%s", name)
+	}
+	p.skipSpace()
+	var s cssSelector
+	switch name {
+	case "not", "is", "where", "has":
+		sel, err := p.parseSelectorList()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "not":
+			s = &cssNot{sel: sel}
+		case "has":
+			s = &cssHas{sel: sel}
+		default:
+			s = sel
+		}
+	case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+		a, b, err := p.parseNth()
+		if err != nil {
+			return nil, err
+		}
+		s = &cssNth{a: a, b: b, last: strings.Contains(name, "last"), ofType: strings.HasSuffix(name, "of-type")}
+	default:
+		p.pos = start
+		return nil, p.errorf("unsupported pseudo-class :%s()", name)
+	}
+	p.skipSpace()
+	if !p.consume(')') {
+		return nil, p.errorf("expected ')'")
+	}
+	return s, nil
+}
+
+func (p *selectorParser) parseNth() (a, b int, err error) {
+	start := p.pos
+	end := strings.IndexByte(p.src[p.pos:], ')')
+	if end < 0 {
+		return 0, 0, p.errorf("expected ')'")
+	}
+	expr := strings.ToLower(strings.Join(strings.Fields(p.src[p.pos:p.pos+end]), ""))
+	switch {
+	case expr == "odd":
+		a, b = 2, 1
+	case expr == "even":
+		a, b = 2, 0
+	case strings.Contains(expr, "n"):
+		i := strings.IndexByte(expr, 'n')
+		switch expr[:i] {
+		case "", "+":
+			a = 1
+		case "-":
+			a = -1
+		default:
+			a, err = strconv.Atoi(expr[:i])
+		}
+		if err == nil && expr[i+1:] != "" {
+			b, err = strconv.Atoi(expr[i+1:])
+		}
+	default:
+		b, err = strconv.Atoi(expr)
+	}
+	if err != nil || expr == "" {
+		return 0, 0, p.errorf("invalid nth expression %q", expr)
+	}
+	p.pos = start + end
+	return a, b, nil
+}
+
+func (p *selectorParser) isNameStart() bool {
+	if p.pos >= len(p.src) {
+		return false
+	}
+	c := p.src[p.pos]
+	if c == '-' && p.pos+1 < len(p.src) {
+		c = p.src[p.pos+1]
+	}
+	return c == '_' || c == '\\' || c >= 0x80 || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return c == '-' || c == '_' || c >= 0x80 || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func (p *selectorParser) parseName() (string, error) {
+	var buf strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '\\' {
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			buf.WriteRune(r)
+			continue
+		}
+		if !isNameChar(c) {
+			break
+		}
+		buf.WriteByte(c)
+		p.pos++
+	}
+	if buf.Len() == 0 {
+		return "", p.errorf("expected name")
+	}
+	return buf.String(), nil
+}
+
+func (p *selectorParser) parseString() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var buf strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case quote:
+			p.pos++
+			return buf.String(), nil
+		case '\\':
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			buf.WriteRune(r)
+		default:
+			buf.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *selectorParser) parseEscape() (rune, error) {
+	p.pos++
+	if p.pos >= len(p.src) {
+		return 0, p.errorf("unexpected end of escape")
+	}
+	i := p.pos
+	for i < len(p.src) && i-p.pos < 6 && strings.IndexByte("0123456789abcdefABCDEF", p.src[i]) >= 0 {
+		i++
+	}
+	if i > p.pos {
+		v, _ := strconv.ParseUint(p.src[p.pos:i], 16, 32)
+		p.pos = i
+		if p.pos < len(p.src) && p.src[p.pos] == ' ' {
+			p.pos++
+		}
+		if v == 0 || v > utf8.MaxRune {
+			return utf8.RuneError, nil
+		}
+		return rune(v), nil
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	return r, nil
+}
@@ -0,0 +1,169 @@
+package supersimplesoup
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{"title", ""},
+		{"a", "a-id-1"},
+		{"#a-id-3", "a-id-3"},
+		{"a.a-class-2", "a-id-5"},
+		{"ul#ul-id-2 a", "a-id-5"},
+		{"li + li > a", "a-id-3"},
+		{"li ~ li a:last-child", "a-id-4"},
+		{"a[href$='-7']", "a-id-7"},
+		{"a[title^=A-TITLE-6 i]", "a-id-6"},
+		{"ul:nth-of-type(2) li:nth-child(2) a:nth-child(2)", "a-id-8"},
+		{"li:not(#li-id-1) a", "a-id-3"},
+		{"ul:has(.a-class-2)", "ul-id-2"},
+		{"#a-id-9, #a-id-2", "a-id-2"},
+	}
+
+	for _, test := range tests {
+		node := root.Select(test.sel)
+		if node == nil {
+			t.Errorf("`%s` element node count, want %d, got %d", test.sel, 1, 0)
+			continue
+		}
+		if got := node.ID(); test.want != got {
+			t.Errorf("`%s` element attribute id, want %q, got %q", test.sel, test.want, got)
+		}
+	}
+
+	s, _ := compileCached("ul#ul-id-2 a")
+	if got, _ := compileCached("ul#ul-id-2 a"); got != s {
+		t.Errorf("`%s` compiled selector, want cached", "ul#ul-id-2 a")
+	}
+	for i := 0; i < selectorCacheSize; i++ {
+		root.Select("#a-id-" + strconv.Itoa(i))
+	}
+	if got, _ := compileCached("ul#ul-id-2 a"); got == s || len(selectorCache.elems) != selectorCacheSize || selectorCache.list.Len() != selectorCacheSize {
+		t.Errorf("selector cache, want %d least recently used, got %d", selectorCacheSize, len(selectorCache.elems))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("`%s` select, want panic", "a[")
+		}
+	}()
+	root.Select("a[")
+}
+
+func TestSelectAll(t *testing.T) {
+	tests := []struct {
+		sel  string
+		want int
+	}{
+		{"*", 19},
+		{"html:root", 1},
+		{"ul", 2},
+		{"ul > li", 4},
+		{"div > li", 0},
+		{"li:first-child", 2},
+		{"a:only-child", 0},
+		{"li:nth-child(odd) a", 4},
+		{"a:nth-last-child(-n+1)", 4},
+		{"[class|=a]", 8},
+		{"[class*=class-1]", 7},
+		{"a[id][title~='a-title-1']", 1},
+		{"title, a.a-class-1", 5},
+		{"ul a:is(#a-id-1, #a-id-8)", 2},
+		{"li:empty", 0},
+		{"li:has(ul a)", 0},
+		{"li:has(li a)", 0},
+		{"body:has(ul a, p)", 1},
+	}
+
+	for _, test := range tests {
+		nodes := root.SelectAll(test.sel)
+		if got := len(nodes); test.want != got {
+			t.Errorf("`%s` element node count, want %d, got %d", test.sel, test.want, got)
+		}
+	}
+
+	for _, sel := range []string{"a[", "a >", ":unknown"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("`%s` select all, want panic", sel)
+				}
+			}()
+			Nodes{root}.SelectAll(sel)
+		}()
+	}
+}
+
+func TestSelectChain(t *testing.T) {
+	want := []string{"a-id-1", "a-id-3", "a-id-5", "a-id-7"}
+	var got []string
+	for _, node := range root.SelectAll("ul").SelectAll("li").Select("a") {
+		got = append(got, node.ID())
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("`%s` element attribute id, want %v, got %v", "$$ul.$$li.a", want, got)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		sel    string
+		offset int
+	}{
+		{"ul > li a", -1},
+		{"", 0},
+		{"a[", 2},
+		{"a >", 3},
+		{"a[href~]", 6},
+		{"a:unknown", 2},
+		{"li:nth-child(x)", 13},
+		{"a, ", 3},
+		{"a)", 1},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.sel)
+		if test.offset < 0 {
+			if err != nil {
+				t.Errorf("`%s` compile, want no error, got %v", test.sel, err)
+			}
+			continue
+		}
+		serr, ok := err.(*SelectorError)
+		if !ok {
+			t.Errorf("`%s` compile, want *SelectorError, got %v", test.sel, err)
+			continue
+		}
+		if test.offset != serr.Offset {
+			t.Errorf("`%s` compile error offset, want %d, got %d", test.sel, test.offset, serr.Offset)
+		}
+	}
+}
+
+func TestSelector(t *testing.T) {
+	s := MustCompile("li#li-id-3 > a")
+	if want, got := "a-id-5", s.First(root).ID(); want != got {
+		t.Errorf("`%s` first element attribute id, want %q, got %q", s, want, got)
+	}
+	if want, got := 2, len(s.All(root)); want != got {
+		t.Errorf("`%s` element node count, want %d, got %d", s, want, got)
+	}
+	if !s.Match(root.Query("a", "id", "a-id-6")) {
+		t.Errorf("`%s` match a-id-6, want true, got false", s)
+	}
+	if s.Match(root.Query("a", "id", "a-id-7")) {
+		t.Errorf("`%s` match a-id-7, want false, got true", s)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("`%s` must compile, want panic", "a[")
+		}
+	}()
+	MustCompile("a[")
+}
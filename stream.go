@@ -0,0 +1,135 @@
+package supersimplesoup
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"iter"
+)
+
+// Stream returns an iterator over the element nodes matched by the specified tag and optional attribute key and value pairs
+// in the HTML from the given Reader, with the same matching as QueryAll.
+//
+// The input is tokenized incrementally, only the matched subtrees are built and everything else is discarded,
+// so the memory usage is bounded by the largest matched subtree rather than the whole input.
+// Each outermost matched subtree is parsed by html.ParseFragment in the context of its parent element,
+// where the end of the subtree is found by the end tags and the common omitted end tags of the HTML syntax.
+// All the yielded nodes are detached, and a node matched inside a matched subtree is yielded as a deep clone.
+//
+// The iterator yields a non-nil error and stops if reading the input fails or the regular expression is invalid.
+// The input is assumed to be UTF-8 encoded.
+func Stream(r io.Reader, tag string, attrkv ...string) iter.Seq2[*Node, error] {
+	conds, err := plainAttrs(attrkv)
+	return func(yield func(*Node, error) bool) {
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		z := html.NewTokenizer(r)
+		var open openElements
+		// capture is the index of the matched element in open, or -1 if none is being captured.
+		capture := -1
+		var buf []byte
+		var context *html.Node
+		// emit parses the captured subtree and yields the nodes matched in it.
+		emit := func() bool {
+			capture = -1
+			nodes, err := html.ParseFragment(bytes.NewReader(buf), context)
+			if err != nil {
+				return yield(nil, err)
+			}
+			var found Nodes
+			for _, top := range nodes {
+				Walk((*Node)(top), func(node *Node) error {
+					if !match(node, tag, conds) {
+						return nil
+					}
+					if node.Parent != nil {
+						node = node.Clone(true)
+					}
+					found = append(found, node)
+					return nil
+				})
+			}
+			for _, node := range found {
+				if !yield(node, nil) {
+					return false
+				}
+			}
+			return true
+		}
+		for {
+			open.follow(z)
+			tt := z.Next()
+			if tt == html.ErrorToken {
+				if err := z.Err(); err != io.EOF {
+					yield(nil, err)
+				} else if capture >= 0 {
+					emit()
+				}
+				return
+			}
+			switch tt {
+			case html.StartTagToken, html.SelfClosingTagToken:
+				// The raw bytes are copied before the attributes are unescaped in place.
+				raw := append([]byte(nil), z.Raw()...)
+				name, more := z.TagName()
+				t := string(name)
+				if !isRootTag(t) {
+					open.closeImplied(t)
+				}
+				if capture >= 0 && len(open.tags) <= capture && !emit() {
+					return
+				}
+				if capture < 0 {
+					n := &Node{Type: html.ElementNode, Data: t}
+					for more {
+						var key, val []byte
+						key, val, more = z.TagAttr()
+						n.Attr = append(n.Attr, html.Attribute{Key: string(key), Val: string(val)})
+					}
+					if match(n, tag, conds) {
+						capture, buf, context = len(open.tags), nil, contextElement(&open)
+					}
+				}
+				if capture >= 0 {
+					buf = append(buf, raw...)
+				}
+				if !isRootTag(t) {
+					open.followStart(z)
+				}
+				if isRootTag(t) || open.keepsOpen(t, tt == html.SelfClosingTagToken) {
+					open.push(t, -1)
+				} else if capture == len(open.tags) && !emit() {
+					return
+				}
+			case html.EndTagToken:
+				name, _ := z.TagName()
+				i := open.index(string(name))
+				if capture >= 0 && (i < 0 || i >= capture) {
+					buf = append(buf, z.Raw()...)
+				}
+				if i >= 0 {
+					open.truncate(i)
+				}
+				if capture >= 0 && len(open.tags) <= capture && !emit() {
+					return
+				}
+			default:
+				if capture >= 0 {
+					buf = append(buf, z.Raw()...)
+				}
+			}
+		}
+	}
+}
+
+// contextElement returns the element to parse the fragment opened next in, which is the innermost open element, or body if none.
+func contextElement(open *openElements) *html.Node {
+	tag := "body"
+	if n := len(open.tags); n > 0 {
+		tag = open.tags[n-1]
+	}
+	return &html.Node{Type: html.ElementNode, Data: tag, DataAtom: atom.Lookup([]byte(tag)), Namespace: open.namespace()}
+}
@@ -0,0 +1,133 @@
+package supersimplesoup
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		tag    string
+		attrkv []string
+	}{
+		{"title", nil},
+		{"ul", nil},
+		{"li", []string{"id", "li-id-3"}},
+		{"a", nil},
+		{"a", []string{"class", "a-class-2"}},
+		{"", []string{"id", "^=li-"}},
+		{"", []string{"title"}},
+	}
+
+	for _, test := range tests {
+		var want, got []string
+		for _, node := range root.QueryAll(test.tag, test.attrkv...) {
+			want = append(want, node.HTML())
+		}
+		for node, err := range Stream(strings.NewReader(testHTML), test.tag, test.attrkv...) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, node.HTML())
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("`%s` streamed html, want %q, got %q", prettyTagAttr(test.tag, test.attrkv), want, got)
+		}
+	}
+}
+
+func TestStreamLikeQueryAll(t *testing.T) {
+	tests := []struct {
+		src string
+		tag string
+	}{
+		{`<p>a<div>b<p>c<span>d</div>e`, "p"},
+		{`<p>a<div>b<p>c<span>d</div>e`, "div"},
+		{`<dl><dt>a<dd>b<dt>c</dl><ol><li>x<li>y</ol>`, "dd"},
+		{`<select><option>1<option>2<optgroup><option>3</select>`, "option"},
+		{`<h1>a<h2>b</h2>`, "h1"},
+		{`<svg><![CDATA[<p>]]></svg><p>x</p>`, "p"},
+		{`<svg><style><p>a</p></style></svg><p>b</p>`, "p"},
+		{`<svg><g><p>a<p>b</svg>`, "p"},
+	}
+	for _, test := range tests {
+		doc, err := Parse(strings.NewReader(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want, got []string
+		for _, node := range doc.QueryAll(test.tag) {
+			want = append(want, node.HTML())
+		}
+		for node, err := range Stream(strings.NewReader(test.src), test.tag) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, node.HTML())
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("`%s` streamed %s html, want %q, got %q", test.src, test.tag, want, got)
+		}
+	}
+}
+
+func TestStreamImpliedEnds(t *testing.T) {
+	tests := []struct {
+		src  string
+		tag  string
+		want []string
+	}{
+		{`<ul><li>a<li>b<ul><li>c</ul></ul>`, "li", []string{"<li>a</li>", "<li>b<ul><li>c</li></ul></li>", "<li>c</li>"}},
+		{`<table><tr><td>1<td>2<tr><td>3</table>`, "tr", []string{"<tr><td>1</td><td>2</td></tr>", "<tr><td>3</td></tr>"}},
+		{`<div>a<span>b</div><div>c</div>`, "div", []string{"<div>a<span>b</span></div>", "<div>c</div>"}},
+		{`<div>a</span>b</div>`, "div", []string{"<div>ab</div>"}},
+		{`<p>a<img src="x">b<br/>c`, "p", []string{`<p>a<img src="x"/>b<br/>c</p>`}},
+		{`<p>a<img src="x">b<br/>c`, "img", []string{`<img src="x"/>`}},
+		{`<p>a<div>b</div><p>c<ul><li>d</ul>`, "p", []string{"<p>a</p>", "<p>c</p>"}},
+		{`<table><tr><td>1<td>2</table>`, "td", []string{"<td>1</td>", "<td>2</td>"}},
+		{`<svg><g id="x"><circle r="1"/></g></svg>`, "g", []string{`<g id="x"><circle r="1"></circle></g>`}},
+		{`<ul><li class="x">a &amp; b</li></ul>`, "li", []string{"<li class=\"x\">a &amp; b</li>"}},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for node, err := range Stream(strings.NewReader(test.src), test.tag) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if node.ParentNode() != nil || node.Data != test.tag {
+				t.Errorf("`%s` streamed node is not detached %s", test.src, test.tag)
+			}
+			got = append(got, node.HTML())
+		}
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("`%s` streamed html, want %q, got %q", test.src, test.want, got)
+		}
+	}
+}
+
+func TestStreamBreak(t *testing.T) {
+	var got []string
+	for node := range Stream(strings.NewReader(testHTML), "a") {
+		got = append(got, node.ID())
+		if len(got) == 2 {
+			break
+		}
+	}
+	if want := []string{"a-id-1", "a-id-2"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("streamed ids, want %v, got %v", want, got)
+	}
+
+	errRead := errors.New("read failed")
+	var gotErr error
+	for _, err := range Stream(io.MultiReader(strings.NewReader(testHTML), iotest.ErrReader(errRead)), "a") {
+		gotErr = err
+	}
+	if gotErr != errRead {
+		t.Errorf("stream reader error, want %v, got %v", errRead, gotErr)
+	}
+}
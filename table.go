@@ -0,0 +1,272 @@
+package supersimplesoup
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxColspan and maxRowspan are the max values of the colspan and rowspan attributes, as the HTML table model.
+const (
+	maxColspan = 1000
+	maxRowspan = 65534
+)
+
+// Table is the normalized grid of a table element node, where the cells spanning several rows or columns are repeated.
+type Table struct {
+	Caption string     // the text of the caption element
+	Header  []string   // the column headers, empty if the table has no header row
+	Rows    [][]string // the text of the cells of the body rows, all of the same length as the columns
+	cells   [][]*Node  // the cell element nodes of the body rows, nil for the missing cells
+}
+
+// Table returns the normalized grid of the table element node.
+//
+// The rows are the tr element nodes in the thead, the tbody and the tfoot element nodes in order,
+// or the direct children tr element nodes. The rows in the thead element node are the header rows,
+// otherwise the first row is the header row if all of its cells are th element nodes.
+// The headers of several header rows are joined by a space. It doesn't descend into the nested tables.
+func (n *Node) Table() (*Table, error) {
+	if n == nil {
+		return nil, fmt.Errorf("not allow to build table from a blank node")
+	}
+	if !n.IsElementNode() || n.Data != "table" {
+		return nil, fmt.Errorf("not a table element `%s`", n.Data)
+	}
+
+	var heads, bodies, foots [][]*Node
+	t := &Table{}
+	var bodyRows []*Node
+	flush := func() {
+		if len(bodyRows) > 0 {
+			bodies = append(bodies, bodyRows)
+			bodyRows = nil
+		}
+	}
+	for c := (*Node)(n.FirstChild); c != nil; c = (*Node)(c.NextSibling) {
+		if !c.IsElementNode() {
+			continue
+		}
+		switch c.Data {
+		case "caption":
+			if t.Caption == "" {
+				t.Caption = collapseSpace(c.FullText())
+			}
+		case "thead":
+			flush()
+			heads = append(heads, tableRows(c))
+		case "tbody":
+			flush()
+			bodies = append(bodies, tableRows(c))
+		case "tfoot":
+			flush()
+			foots = append(foots, tableRows(c))
+		case "tr":
+			bodyRows = append(bodyRows, c)
+		}
+	}
+	flush()
+
+	var head [][]*Node
+	for _, group := range heads {
+		head = append(head, tableGrid(group)...)
+	}
+	var body [][]*Node
+	for _, group := range bodies {
+		body = append(body, tableGrid(group)...)
+	}
+	for _, group := range foots {
+		body = append(body, tableGrid(group)...)
+	}
+	if len(head) == 0 && len(body) > 0 && isHeaderRow(body[0]) {
+		head, body = body[:1], body[1:]
+	}
+
+	width := 0
+	for _, row := range head {
+		width = max(width, len(row))
+	}
+	for _, row := range body {
+		width = max(width, len(row))
+	}
+	if len(head) > 0 {
+		t.Header = make([]string, width)
+		for i := range t.Header {
+			var labels []string
+			for j, row := range head {
+				if i >= len(row) || row[i] == nil {
+					continue
+				}
+				// The cell spanning several header rows makes one label.
+				if j > 0 && i < len(head[j-1]) && head[j-1][i] == row[i] {
+					continue
+				}
+				if label := collapseSpace(row[i].FullText()); label != "" && !containsString(labels, label) {
+					labels = append(labels, label)
+				}
+			}
+			t.Header[i] = strings.Join(labels, " ")
+		}
+	}
+	t.Rows = make([][]string, len(body))
+	t.cells = make([][]*Node, len(body))
+	for i, row := range body {
+		t.cells[i] = make([]*Node, width)
+		copy(t.cells[i], row)
+		t.Rows[i] = make([]string, width)
+		for j, cell := range t.cells[i] {
+			if cell != nil {
+				t.Rows[i][j] = collapseSpace(cell.FullText())
+			}
+		}
+	}
+	return t, nil
+}
+
+// tableRows returns the direct children tr element nodes of the row group.
+func tableRows(group *Node) (rows []*Node) {
+	for c := (*Node)(group.FirstChild); c != nil; c = (*Node)(c.NextSibling) {
+		if c.IsElementNode() && c.Data == "tr" {
+			rows = append(rows, c)
+		}
+	}
+	return
+}
+
+// tableGrid returns the cells of the rows of one row group, where the spanning cells are repeated.
+// The rowspan doesn't extend beyond the row group, and rowspan=0 extends to the end of it.
+func tableGrid(rows []*Node) [][]*Node {
+	grid := make([][]*Node, len(rows))
+	for i, tr := range rows {
+		col := 0
+		for c := (*Node)(tr.FirstChild); c != nil; c = (*Node)(c.NextSibling) {
+			if !c.IsElementNode() || (c.Data != "td" && c.Data != "th") {
+				continue
+			}
+			for col < len(grid[i]) && grid[i][col] != nil {
+				col++
+			}
+			colspan := spanAttr(c, "colspan", 1, maxColspan)
+			rowspan := spanAttr(c, "rowspan", 0, maxRowspan)
+			if rowspan == 0 {
+				rowspan = len(rows) - i
+			}
+			for r := i; r < i+rowspan && r < len(rows); r++ {
+				for len(grid[r]) < col+colspan {
+					grid[r] = append(grid[r], nil)
+				}
+				for k := col; k < col+colspan; k++ {
+					if grid[r][k] == nil {
+						grid[r][k] = c
+					}
+				}
+			}
+			col += colspan
+		}
+	}
+	return grid
+}
+
+func spanAttr(cell *Node, key string, lo, hi int) int {
+	for _, attr := range cell.Attr {
+		if attr.Key != key {
+			continue
+		}
+		v, err := strconv.Atoi(strings.TrimSpace(attr.Val))
+		if err != nil || v < lo {
+			return 1
+		}
+		if v > hi {
+			return hi
+		}
+		return v
+	}
+	return 1
+}
+
+func isHeaderRow(row []*Node) bool {
+	for _, cell := range row {
+		if cell == nil || cell.Data != "th" {
+			return false
+		}
+	}
+	return len(row) > 0
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteCSV writes the header row if any and the rows to w as CSV.
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if len(t.Header) > 0 {
+		if err := cw.Write(t.Header); err != nil {
+			return err
+		}
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// Maps returns the rows as maps keyed by the column headers.
+//
+// The columns without header or with the duplicated header are keyed by their 1-based index.
+func (t *Table) Maps() []map[string]string {
+	width := len(t.Header)
+	if len(t.Rows) > 0 {
+		width = len(t.Rows[0])
+	}
+	keys := make([]string, width)
+	for i := range keys {
+		if i < len(t.Header) && t.Header[i] != "" && !containsString(keys[:i], t.Header[i]) {
+			keys[i] = t.Header[i]
+		} else {
+			keys[i] = strconv.Itoa(i + 1)
+		}
+	}
+	maps := make([]map[string]string, len(t.Rows))
+	for i, row := range t.Rows {
+		maps[i] = make(map[string]string, len(row))
+		for j, v := range row {
+			maps[i][keys[j]] = v
+		}
+	}
+	return maps
+}
+
+// Unmarshal fills the slice of structs pointed to by v from the rows, one element for each row.
+//
+// Each row is unmarshalled as Unmarshal from a detached tr element node holding the copies of its cells,
+// so the struct fields select the cells by the column, e.g. `soup:"td:nth-child(2)"`.
+// The field paths of the errors start with the row, e.g. Rows[2].Price.
+func (t *Table) Unmarshal(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("unmarshal to non slice pointer %T", v)
+	}
+	rv = rv.Elem()
+	slice := reflect.MakeSlice(rv.Type(), 0, len(t.cells))
+	for i, cells := range t.cells {
+		row := NewElement("tr")
+		for _, cell := range cells {
+			if cell == nil {
+				row.AppendChild(NewElement("td"))
+			} else {
+				row.AppendChild(cell.Clone(true))
+			}
+		}
+		ev := reflect.New(rv.Type().Elem()).Elem()
+		if err := unmarshalValue(row, ev, &fieldTag{}, fmt.Sprintf("Rows[%d]", i)); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, ev)
+	}
+	rv.Set(slice)
+	return nil
+}
@@ -0,0 +1,127 @@
+package supersimplesoup
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testTableHTML = `<table>
+	<caption> Sales  report </caption>
+	<thead>
+		<tr><th rowspan="2">Region</th><th colspan="2">Q1</th><th rowspan="2">Note</th></tr>
+		<tr><th>Sales</th><th>Cost</th></tr>
+	</thead>
+	<tbody>
+		<tr><td rowspan="2">North</td><td>10</td><td>4</td><td>a, "b"</td></tr>
+		<tr><td colspan="2">12</td></tr>
+		<tr><td>South</td><td>
+			7
+		</td></tr>
+	</tbody>
+	<tfoot><tr><td>Total</td><td>29</td><td><table><tr><td>nested</td></tr></table></td></tr></tfoot>
+</table>`
+
+func getTestTable(t *testing.T) *Table {
+	t.Helper()
+	table, err := parseBody(t, testTableHTML).Find("table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tb, err := table.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tb
+}
+
+func TestTable(t *testing.T) {
+	tb := getTestTable(t)
+	if want := "Sales report"; tb.Caption != want {
+		t.Errorf("caption, want %q, got %q", want, tb.Caption)
+	}
+	if want := []string{"Region", "Q1 Sales", "Q1 Cost", "Note"}; !reflect.DeepEqual(want, tb.Header) {
+		t.Errorf("header, want %q, got %q", want, tb.Header)
+	}
+	want := [][]string{
+		{"North", "10", "4", `a, "b"`},
+		{"North", "12", "12", ""},
+		{"South", "7", "", ""},
+		{"Total", "29", "nested", ""},
+	}
+	if !reflect.DeepEqual(want, tb.Rows) {
+		t.Errorf("rows, want %q, got %q", want, tb.Rows)
+	}
+}
+
+func TestTableHeader(t *testing.T) {
+	tests := []struct {
+		html   string
+		header []string
+		rows   int
+	}{
+		{`<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr></table>`, []string{"a", "b"}, 1},
+		{`<table><tr><th>a</th><td>b</td></tr><tr><td>1</td><td>2</td></tr></table>`, nil, 2},
+		{`<table><tr><td rowspan="0">a</td><td>b</td></tr><tr><td>1</td></tr></table>`, nil, 2},
+		{`<table></table>`, nil, 0},
+	}
+	for _, test := range tests {
+		table, err := parseBody(t, test.html).Find("table")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tb, err := table.Table()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(test.header, tb.Header) || len(tb.Rows) != test.rows {
+			t.Errorf("`%s` header and rows, want %q and %d, got %q and %d", test.html, test.header, test.rows, tb.Header, len(tb.Rows))
+		}
+	}
+	if _, err := root.Table(); err == nil {
+		t.Errorf("table from non table node, want error, got nil")
+	}
+}
+
+func TestTableCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := getTestTable(t).WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "Region,Q1 Sales,Q1 Cost,Note\nNorth,10,4,\"a, \"\"b\"\"\"\nNorth,12,12,\nSouth,7,,\nTotal,29,nested,\n"
+	if got := buf.String(); got != want {
+		t.Errorf("csv, want %q, got %q", want, got)
+	}
+}
+
+func TestTableMaps(t *testing.T) {
+	maps := getTestTable(t).Maps()
+	if len(maps) != 4 {
+		t.Fatalf("maps count, want %d, got %d", 4, len(maps))
+	}
+	if want := map[string]string{"Region": "North", "Q1 Sales": "12", "Q1 Cost": "12", "Note": ""}; !reflect.DeepEqual(want, maps[1]) {
+		t.Errorf("map, want %v, got %v", want, maps[1])
+	}
+}
+
+func TestTableUnmarshal(t *testing.T) {
+	var rows []struct {
+		Region string `soup:"td:nth-child(1)"`
+		Sales  int    `soup:"td:nth-child(2)"`
+		Cost   *int   `soup:"td:nth-child(3),optional"`
+	}
+	if err := getTestTable(t).Unmarshal(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[1].Region != "North" || rows[1].Sales != 12 || rows[2].Sales != 7 || rows[2].Cost != nil || *rows[0].Cost != 4 {
+		t.Errorf("rows, got %+v", rows)
+	}
+	var bad []struct {
+		Region int `soup:"td"`
+	}
+	var uerr *UnmarshalError
+	if err := getTestTable(t).Unmarshal(&bad); !errors.As(err, &uerr) || uerr.Path != "Rows[0].Region" {
+		t.Errorf("unmarshal error path, want %q, got %v", "Rows[0].Region", err)
+	}
+}
@@ -0,0 +1,301 @@
+package supersimplesoup
+
+import (
+	"golang.org/x/net/html"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextOptions controls how TextWith extracts the text.
+type TextOptions struct {
+	// Deep makes the text include all the descendant text nodes and this node, rather than the direct child text nodes.
+	Deep bool
+	// Separator is inserted between the texts of the nodes.
+	Separator string
+	// Trim removes the leading and trailing white space of the text of each node.
+	Trim bool
+	// CollapseSpace replaces the white space sequences in the text with a single space.
+	CollapseSpace bool
+	// SkipBlank skips the text nodes which consist only of white space.
+	SkipBlank bool
+	// SkipTags is the tags of the descendant element nodes whose subtrees are skipped, e.g. script and noscript.
+	SkipTags []string
+	// IncludeAlt includes the alt of img and area element nodes and the value of input element nodes except the hidden ones.
+	IncludeAlt bool
+	// IncludeComments includes the comment nodes.
+	IncludeComments bool
+	// MaxLen is the max number of characters of the text, which is truncated if longer. Zero means no limit.
+	MaxLen int
+}
+
+// TextWith returns the text of this node extracted with the specified options.
+//
+// The texts of the nodes which are empty after trimming are skipped, so they are not separated.
+func (n *Node) TextWith(opts TextOptions) string {
+	if n == nil {
+		return ""
+	}
+	var buf strings.Builder
+	count, pieces := 0, 0
+	add := func(s string) error {
+		if opts.SkipBlank && blankRegexp.MatchString(s) {
+			return nil
+		}
+		if opts.Trim {
+			s = strings.TrimSpace(s)
+		}
+		if opts.CollapseSpace {
+			s = collapseSpaceKeepEdges(s)
+		}
+		if s == "" {
+			return nil
+		}
+		if pieces > 0 {
+			s = opts.Separator + s
+		}
+		if opts.CollapseSpace && strings.HasPrefix(s, " ") && strings.HasSuffix(buf.String(), " ") {
+			// The spaces at the edges of the adjacent texts are collapsed as well.
+			s = s[1:]
+		}
+		pieces++
+		if opts.MaxLen > 0 {
+			for _, r := range s {
+				if count == opts.MaxLen {
+					return SkipAll
+				}
+				buf.WriteRune(r)
+				count++
+			}
+			return nil
+		}
+		buf.WriteString(s)
+		return nil
+	}
+	visit := func(node *Node) error {
+		switch node.Type {
+		case html.TextNode:
+			return add(node.Data)
+		case html.CommentNode:
+			if opts.IncludeComments {
+				return add(node.Data)
+			}
+		case html.ElementNode:
+			if node != n && slices.Contains(opts.SkipTags, node.Data) {
+				return SkipNode
+			}
+			if opts.IncludeAlt {
+				switch node.Data {
+				case "img", "area":
+					return add(node.Attribute("alt"))
+				case "input":
+					if !strings.EqualFold(node.Attribute("type"), "hidden") {
+						return add(node.Attribute("value"))
+					}
+				}
+			}
+		}
+		return nil
+	}
+	if opts.Deep {
+		n.Walk(visit)
+	} else {
+		for c := range n.Children() {
+			if visit(c) == SkipAll {
+				break
+			}
+		}
+	}
+	return buf.String()
+}
+
+// collapseSpaceKeepEdges replaces the white space sequences with a single space, keeping a space at the edges if any.
+func collapseSpaceKeepEdges(s string) string {
+	collapsed := collapseSpace(s)
+	if collapsed == "" {
+		if s != "" {
+			return " "
+		}
+		return ""
+	}
+	if r, _ := utf8.DecodeRuneInString(s); unicode.IsSpace(r) {
+		collapsed = " " + collapsed
+	}
+	if r, _ := utf8.DecodeLastRuneInString(s); unicode.IsSpace(r) {
+		collapsed = collapsed + " "
+	}
+	return collapsed
+}
+
+// unrenderedTags is the tags of the element nodes whose contents are not rendered as text.
+var unrenderedTags = map[string]bool{
+	"base": true, "datalist": true, "head": true, "iframe": true, "link": true, "meta": true, "noscript": true,
+	"script": true, "select": true, "style": true, "template": true, "textarea": true, "title": true,
+}
+
+// displayBlockTags is the tags of the element nodes laid out as the blocks by the default style sheet.
+var displayBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "caption": true, "center": true,
+	"dd": true, "details": true, "dialog": true, "dir": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hgroup": true, "hr": true, "legend": true, "li": true, "listing": true,
+	"main": true, "menu": true, "nav": true, "ol": true, "p": true, "plaintext": true, "pre": true, "search": true,
+	"section": true, "summary": true, "table": true, "ul": true, "xmp": true,
+}
+
+// tableStructureTags is the tags of the table element nodes which hold no text but the cells or rows.
+var tableStructureTags = map[string]bool{"table": true, "tbody": true, "tfoot": true, "thead": true, "tr": true}
+
+// preformattedTags is the tags of the element nodes whose white space is preserved.
+var preformattedTags = map[string]bool{"listing": true, "plaintext": true, "pre": true, "xmp": true}
+
+var displayNoneRegexp = regexp.MustCompile(`(?i)(^|;)\s*display\s*:\s*none\s*(;|$|!)`)
+
+// InnerText returns the text of this node as it would be rendered, approximating the innerText of browsers.
+//
+// The white space is collapsed except in pre, the block element nodes start new lines, p element nodes are
+// separated by a blank line, br element nodes break lines, and the table cells and rows are separated by
+// tab and newline. The contents of script, style, template, head and the hidden element nodes are skipped.
+// It returns the full text if this node itself is not rendered, as browsers.
+func (n *Node) InnerText() string {
+	if n == nil {
+		return ""
+	}
+	if n.IsElementNode() && isHiddenElement(n) {
+		return n.FullText()
+	}
+	w := &textWriter{}
+	w.node(n, false)
+	return w.buf.String()
+}
+
+// textWriter writes the rendered text, collapsing the white space and the required line breaks.
+type textWriter struct {
+	buf       strings.Builder
+	space     bool // a collapsed white space is pending
+	lineStart bool // the last written char is a line break
+	breaks    int  // the number of required line breaks pending
+}
+
+// text writes the text, collapsing the white space unless it is preformatted.
+func (w *textWriter) text(s string, pre bool) {
+	if pre {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+		if s == "" {
+			return
+		}
+		w.flush()
+		w.buf.WriteString(s)
+		w.lineStart = strings.HasSuffix(s, "\n")
+		return
+	}
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if w.buf.Len() > 0 && !w.lineStart && w.breaks == 0 {
+				w.space = true
+			}
+			continue
+		}
+		w.flush()
+		if w.space {
+			w.buf.WriteByte(' ')
+			w.space = false
+		}
+		w.buf.WriteRune(r)
+		w.lineStart = false
+	}
+}
+
+// literal writes the string which is never collapsed, e.g. the line break of br.
+func (w *textWriter) literal(s string) {
+	w.flush()
+	w.space = false
+	w.buf.WriteString(s)
+	w.lineStart = strings.HasSuffix(s, "\n")
+}
+
+// require requests count line breaks before the next text, which are collapsed with the adjacent ones.
+func (w *textWriter) require(count int) {
+	w.breaks = max(w.breaks, count)
+	w.space = false
+}
+
+func (w *textWriter) flush() {
+	if w.breaks > 0 && w.buf.Len() > 0 {
+		w.buf.WriteString(strings.Repeat("\n", w.breaks))
+		w.lineStart = true
+	}
+	w.breaks = 0
+}
+
+func (w *textWriter) node(n *Node, pre bool) {
+	if n.IsTextNode() {
+		if p := n.ParentNode(); p != nil && tableStructureTags[p.Data] && strings.TrimSpace(n.Data) == "" {
+			// The white space between the table cells and rows is not rendered.
+			return
+		}
+		w.text(n.Data, pre)
+		return
+	}
+	if n.IsElementNode() {
+		if isHiddenElement(n) {
+			return
+		}
+		pre = pre || preformattedTags[n.Data]
+		switch {
+		case n.Data == "br":
+			w.literal("\n")
+			return
+		case n.Data == "p":
+			w.require(2)
+		case displayBlockTags[n.Data]:
+			w.require(1)
+		}
+	}
+	for c := range n.Children() {
+		w.node(c, pre)
+	}
+	if !n.IsElementNode() {
+		return
+	}
+	switch {
+	case n.Data == "p":
+		w.require(2)
+	case displayBlockTags[n.Data]:
+		w.require(1)
+	case (n.Data == "td" || n.Data == "th") && nextElementSibling(n) != nil:
+		w.literal("\t")
+	case n.Data == "tr" && !isLastRow(n):
+		w.literal("\n")
+	}
+}
+
+// isHiddenElement reports whether the element node is not rendered, by its tag, the hidden attribute or the display style.
+func isHiddenElement(n *Node) bool {
+	if unrenderedTags[n.Data] {
+		return true
+	}
+	if _, ok := lookupAttr(n, "hidden"); ok {
+		return true
+	}
+	return displayNoneRegexp.MatchString(n.Attribute("style"))
+}
+
+// isLastRow reports whether the tr element node is the last row of its table.
+func isLastRow(tr *Node) bool {
+	if nextElementSibling(tr) != nil {
+		return false
+	}
+	group := tr.ParentNode()
+	if group == nil || group.Data == "table" {
+		return true
+	}
+	for s := nextElementSibling(group); s != nil; s = nextElementSibling(s) {
+		if (s.Data == "tbody" || s.Data == "thead" || s.Data == "tfoot") && s.Query("tr") != nil {
+			return false
+		}
+	}
+	return true
+}
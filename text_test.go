@@ -0,0 +1,82 @@
+package supersimplesoup
+
+import (
+	"testing"
+)
+
+func TestInnerText(t *testing.T) {
+	tests := []struct {
+		tag    string
+		attrkv []string
+		want   string
+	}{
+		{"title", nil, "supersimplesoup"},
+		{"li", []string{"id", "li-id-1"}, "a-text-1 a-text-2"},
+		{"ul", []string{"id", "ul-id-2"}, "a-text-5 a-text-6\na-text-7 a-text-8"},
+		{"a", []string{"id", "a-id-8"}, "a-text-8"},
+	}
+	for _, test := range tests {
+		if got := root.Query(test.tag, test.attrkv...).InnerText(); got != test.want {
+			t.Errorf("`%s` inner text, want %q, got %q", prettyTagAttr(test.tag, test.attrkv), test.want, got)
+		}
+	}
+
+	tests2 := []struct {
+		html string
+		want string
+	}{
+		{"  hello \n\t  <b>big</b>   world  ", "hello big world"},
+		{"<p>one</p><p>two</p>", "one\n\ntwo"},
+		{"<div>a</div><div>b<div>c</div></div><p>d</p>e", "a\nb\nc\n\nd\n\ne"},
+		{"line1<br>line2<br><br>line4", "line1\nline2\n\nline4"},
+		{"<div>a <br> b</div>", "a\nb"},
+		{"<pre>  keep\n   this </pre>x", "  keep\n   this \nx"},
+		{"<script>var x;</script><style>p{}</style><template>t</template>visible", "visible"},
+		{"<span hidden>h</span><span style=\"color: red; display : none\">n</span><span style=\"display:inline\">s</span>", "s"},
+		{"<table><thead><tr><th>A</th><th>B</th></tr></thead><tbody><tr><td>1</td><td>2</td></tr><tr><td>3</td><td>4</td></tr></tbody></table>after", "A\tB\n1\t2\n3\t4\nafter"},
+		{"<table>\n  <tr>\n    <td>1</td>\n    <td>2</td>\n  </tr>\n  <tr> <td>3</td> <td>4</td> </tr>\n</table>", "1\t2\n3\t4"},
+		{"<ul>\n  <li>x</li>\n  <li>y</li>\n</ul>", "x\ny"},
+		{"a &amp; b&nbsp;c &lt;d&gt;", "a & b\u00a0c <d>"},
+		{"<p> </p><p>  </p>", ""},
+	}
+	for _, test := range tests2 {
+		if got := parseBody(t, test.html).InnerText(); got != test.want {
+			t.Errorf("`%s` inner text, want %q, got %q", test.html, test.want, got)
+		}
+	}
+}
+
+func TestTextWith(t *testing.T) {
+	n := parseBody(t, `<div>
+		Hello,
+		<b>big   world</b>
+		<script>var x;</script><noscript>enable js</noscript>
+		<img alt="logo"><input value="typed"><input type="hidden" value="secret">
+		<!-- note -->
+	</div>`).Query("div")
+	tests := []struct {
+		opts TextOptions
+		want string
+	}{
+		{TextOptions{}, "\n\t\tHello,\n\t\t\n\t\t\n\t\t\n\t\t\n\t"},
+		{TextOptions{SkipBlank: true}, "\n\t\tHello,\n\t\t"},
+		{TextOptions{Trim: true}, "Hello,"},
+		{TextOptions{Deep: true, Trim: true, Separator: "|"}, "Hello,|big   world|var x;|enable js"},
+		{TextOptions{Deep: true, Trim: true, CollapseSpace: true, Separator: " ", SkipTags: []string{"script", "noscript"}}, "Hello, big world"},
+		{TextOptions{Deep: true, Trim: true, Separator: " ", SkipTags: []string{"script", "noscript"}, IncludeAlt: true, IncludeComments: true}, "Hello, big   world logo typed note"},
+		{TextOptions{Deep: true, CollapseSpace: true, SkipTags: []string{"script", "noscript"}}, " Hello, big world "},
+		{TextOptions{Deep: true, Trim: true, Separator: " ", MaxLen: 9}, "Hello, bi"},
+		{TextOptions{Deep: true, Trim: true, Separator: " ", MaxLen: 7}, "Hello, "},
+	}
+	for i, test := range tests {
+		if got := n.TextWith(test.opts); got != test.want {
+			t.Errorf("%d text with %+v, want %q, got %q", i, test.opts, test.want, got)
+		}
+	}
+	if got, want := n.Text(), n.TextWith(TextOptions{SkipBlank: true}); got != want {
+		t.Errorf("text preset, want %q, got %q", want, got)
+	}
+	if got, want := n.FullText(), n.TextWith(TextOptions{Deep: true}); got != want {
+		t.Errorf("full text preset, want %q, got %q", want, got)
+	}
+}
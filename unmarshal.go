@@ -0,0 +1,304 @@
+package supersimplesoup
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is wrapped by the UnmarshalError of a required field whose element node or attribute is not found.
+var ErrNotFound = errors.New("not found")
+
+// UnmarshalError describes a field which failed to unmarshal.
+type UnmarshalError struct {
+	Path string // the field path, e.g. Page.Items[2].Price
+	Err  error  // the underlying error
+}
+
+func (e *UnmarshalError) Error() string {
+	return fmt.Sprintf("unmarshal `%s`: %v", e.Path, e.Err)
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+// Unmarshal fills the struct pointed to by v from the node, according to the soup tags of the struct fields.
+//
+// The tag is a CSS selector followed by the optional comma separated options, e.g. `soup:"h1.title"` or `soup:"a,attr=href"`.
+// The selector matches the child element nodes of the node, and an empty selector means the node itself.
+// The options are:
+//
+//	attr=name    use the attribute instead of the text
+//	html         use the HTML source code instead of the text
+//	all          match all the element nodes, which is implied by a slice field and not allowed on the other fields
+//	layout=l     parse the time.Time field with the layout instead of time.RFC3339
+//	required     report an error wrapping ErrNotFound if the element node or attribute is not found
+//	optional     leave the field zero if the value fails to convert, rather than report an error
+//
+// The text is the full text of the matched node with the leading and trailing white space removed,
+// which is converted to the field of string, bool, integer, float, time.Time, url.URL or encoding.TextUnmarshaler.
+// A struct field is filled recursively with the matched node as the scope, and each element of a slice field
+// is filled from one of the matched nodes, where the nodes without the attribute are skipped unless required.
+// The fields without soup tag or with the tag "-" are ignored.
+func Unmarshal(node *Node, v any) error {
+	if node == nil {
+		return fmt.Errorf("not allow to unmarshal a blank node")
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal to non struct pointer %T", v)
+	}
+	rv = rv.Elem()
+	return unmarshalStruct(node, rv, rv.Type().Name())
+}
+
+type fieldTag struct {
+	sel      *Selector
+	attr     string
+	layout   string
+	html     bool
+	all      bool
+	required bool
+	optional bool
+}
+
+var fieldSelectors sync.Map
+
+func parseFieldTag(tag string) (ft fieldTag, err error) {
+	parts := strings.Split(tag, ",")
+	// The selector may contain commas, so the options are taken from the end.
+	i := len(parts)
+options:
+	for ; i > 1; i-- {
+		opt := strings.TrimSpace(parts[i-1])
+		switch {
+		case strings.HasPrefix(opt, "attr="):
+			ft.attr = opt[len("attr="):]
+		case strings.HasPrefix(opt, "layout="):
+			ft.layout = opt[len("layout="):]
+		case opt == "html":
+			ft.html = true
+		case opt == "all":
+			ft.all = true
+		case opt == "required":
+			ft.required = true
+		case opt == "optional":
+			ft.optional = true
+		default:
+			break options
+		}
+	}
+	sel := strings.TrimSpace(strings.Join(parts[:i], ","))
+	if sel == "" {
+		return ft, nil
+	}
+	if s, ok := fieldSelectors.Load(sel); ok {
+		ft.sel = s.(*Selector)
+		return ft, nil
+	}
+	if ft.sel, err = Compile(sel); err != nil {
+		return ft, err
+	}
+	fieldSelectors.Store(sel, ft.sel)
+	return ft, nil
+}
+
+func (ft *fieldTag) first(scope *Node) *Node {
+	if ft.sel == nil {
+		return scope
+	}
+	return ft.sel.First(scope)
+}
+
+func (ft *fieldTag) find(scope *Node) Nodes {
+	if ft.sel == nil {
+		return Nodes{scope}
+	}
+	return ft.sel.All(scope)
+}
+
+func (ft *fieldTag) value(n *Node) (string, bool) {
+	switch {
+	case ft.attr != "":
+		for _, attr := range n.Attr {
+			if attr.Key == ft.attr {
+				return strings.TrimSpace(attr.Val), true
+			}
+		}
+		return "", false
+	case ft.html:
+		return n.HTML(), true
+	}
+	return strings.TrimSpace(n.FullText()), true
+}
+
+func (ft *fieldTag) describe() string {
+	s := "element"
+	if ft.sel != nil {
+		s = fmt.Sprintf("element `%s`", ft.sel)
+	}
+	if ft.attr != "" {
+		s = fmt.Sprintf("attribute `%s` of %s", ft.attr, s)
+	}
+	return s
+}
+
+func unmarshalStruct(scope *Node, rv reflect.Value, path string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, ok := f.Tag.Lookup("soup")
+		if !ok || tag == "-" || !f.IsExported() {
+			continue
+		}
+		fpath := path + "." + f.Name
+		ft, err := parseFieldTag(tag)
+		if err != nil {
+			return &UnmarshalError{Path: fpath, Err: err}
+		}
+		if ft.all && f.Type.Kind() != reflect.Slice {
+			return &UnmarshalError{Path: fpath, Err: fmt.Errorf("option `all` on non slice field of %s", f.Type)}
+		}
+		if err := unmarshalField(scope, rv.Field(i), &ft, fpath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalField(scope *Node, fv reflect.Value, ft *fieldTag, path string) error {
+	if fv.Kind() == reflect.Slice {
+		nodes := ft.find(scope)
+		if len(nodes) == 0 && ft.required {
+			return &UnmarshalError{Path: path, Err: fmt.Errorf("%w: %s", ErrNotFound, ft.describe())}
+		}
+		slice := reflect.MakeSlice(fv.Type(), 0, len(nodes))
+		for i, n := range nodes {
+			if ft.attr != "" && !ft.required {
+				if _, ok := lookupAttr(n, ft.attr); !ok {
+					continue
+				}
+			}
+			ev := reflect.New(fv.Type().Elem()).Elem()
+			if err := unmarshalValue(n, ev, ft, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				if ft.optional && !errors.Is(err, ErrNotFound) {
+					continue
+				}
+				return err
+			}
+			slice = reflect.Append(slice, ev)
+		}
+		fv.Set(slice)
+		return nil
+	}
+	n := ft.first(scope)
+	if n == nil {
+		if ft.required {
+			return &UnmarshalError{Path: path, Err: fmt.Errorf("%w: %s", ErrNotFound, ft.describe())}
+		}
+		return nil
+	}
+	if err := unmarshalValue(n, fv, ft, path); err != nil {
+		if ft.optional && !errors.Is(err, ErrNotFound) {
+			fv.Set(reflect.Zero(fv.Type()))
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	urlType  = reflect.TypeOf(url.URL{})
+)
+
+func unmarshalValue(n *Node, v reflect.Value, ft *fieldTag, path string) error {
+	if v.Kind() == reflect.Pointer {
+		ev := reflect.New(v.Type().Elem())
+		if err := unmarshalValue(n, ev.Elem(), ft, path); err != nil {
+			return err
+		}
+		v.Set(ev)
+		return nil
+	}
+	_, isText := v.Addr().Interface().(encoding.TextUnmarshaler)
+	if v.Kind() == reflect.Struct && v.Type() != timeType && v.Type() != urlType && !isText {
+		return unmarshalStruct(n, v, path)
+	}
+	s, ok := ft.value(n)
+	if !ok {
+		if ft.required {
+			return &UnmarshalError{Path: path, Err: fmt.Errorf("%w: %s", ErrNotFound, ft.describe())}
+		}
+		return nil
+	}
+	if err := convertValue(s, v, ft); err != nil {
+		return &UnmarshalError{Path: path, Err: err}
+	}
+	return nil
+}
+
+func convertValue(s string, v reflect.Value, ft *fieldTag) error {
+	switch v.Type() {
+	case timeType:
+		layout := ft.layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case urlType:
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	}
+	if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
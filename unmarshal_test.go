@@ -0,0 +1,146 @@
+package supersimplesoup
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testLink struct {
+	ID    string  `soup:",attr=id"`
+	Href  url.URL `soup:",attr=href"`
+	Text  string  `soup:""`
+	Title *string `soup:",attr=title"`
+}
+
+type testList struct {
+	ID    string     `soup:",attr=id,required"`
+	Links []testLink `soup:"a,all"`
+	First testLink   `soup:"li:first-child a"`
+	Count int        `soup:"li:last-child > a:nth-child(2),attr=data-count,optional"`
+}
+
+type testPage struct {
+	Title string     `soup:"head > title,required"`
+	Lists []testList `soup:"ul"`
+	Hrefs []string   `soup:"ul#ul-id-2 a, ul#ul-id-1 a[href$='1'],attr=href"`
+	Skip  string     `soup:"-"`
+	Plain string
+}
+
+func TestUnmarshal(t *testing.T) {
+	var page testPage
+	if err := Unmarshal(root, &page); err != nil {
+		t.Fatal(err)
+	}
+	if want := "supersimplesoup"; page.Title != want {
+		t.Errorf("title, want %q, got %q", want, page.Title)
+	}
+	if len(page.Lists) != 2 || page.Lists[1].ID != "ul-id-2" || len(page.Lists[1].Links) != 4 {
+		t.Fatalf("lists, got %+v", page.Lists)
+	}
+	link := page.Lists[1].Links[2]
+	if link.ID != "a-id-7" || link.Href.Path != "a-href-7" || link.Text != "a-text-7" || link.Title == nil || *link.Title != "a-title-7" {
+		t.Errorf("link, got %+v", link)
+	}
+	if page.Lists[0].First.ID != "a-id-1" {
+		t.Errorf("first link id, want %q, got %q", "a-id-1", page.Lists[0].First.ID)
+	}
+	if want := []string{"a-href-1", "a-href-5", "a-href-6", "a-href-7", "a-href-8"}; !reflect.DeepEqual(want, page.Hrefs) {
+		t.Errorf("hrefs, want %v, got %v", want, page.Hrefs)
+	}
+}
+
+func TestUnmarshalConvert(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`
+		<div id="item" data-price="12.5" data-stock="3" data-sale="true">
+			<time datetime="2023-01-02T03:04:05Z">2 Jan 2023</time>
+			<span class="qty"> 42 </span>
+			<span class="bad">x</span>
+		</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var item struct {
+		Price   float64   `soup:"#item,attr=data-price"`
+		Stock   uint8     `soup:"#item,attr=data-stock"`
+		Sale    bool      `soup:"#item,attr=data-sale"`
+		Time    time.Time `soup:"time,attr=datetime"`
+		Date    time.Time `soup:"time,layout=2 Jan 2006"`
+		Qty     *int      `soup:"span.qty"`
+		Bad     int       `soup:"span.bad,optional"`
+		Missing *string   `soup:"span.missing"`
+	}
+	if err := Unmarshal(doc, &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Price != 12.5 || item.Stock != 3 || !item.Sale || item.Qty == nil || *item.Qty != 42 || item.Bad != 0 || item.Missing != nil {
+		t.Errorf("item, got %+v", item)
+	}
+	if want := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC); !item.Time.Equal(want) || !item.Date.Equal(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("item time, got %v and %v", item.Time, item.Date)
+	}
+}
+
+func TestUnmarshalSliceAttr(t *testing.T) {
+	var v struct {
+		URLs   []url.URL `soup:"a,attr=href"`
+		Counts []int     `soup:"span,attr=data-count"`
+	}
+	if err := Unmarshal(parseBody(t, `<a href="/x">x</a><a name="y">y</a><a href="/z">z</a><span>0</span><span data-count="2">2</span>`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.URLs) != 2 || v.URLs[0].Path != "/x" || v.URLs[1].Path != "/z" || !reflect.DeepEqual(v.Counts, []int{2}) {
+		t.Errorf("slices of attributes, want the nodes without attribute skipped, got %v and %v", v.URLs, v.Counts)
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	var missing struct {
+		Lists []struct {
+			Items []struct {
+				Rel string `soup:"a,attr=rel,required"`
+			} `soup:"li"`
+		} `soup:"ul"`
+	}
+	err := Unmarshal(root, &missing)
+	var uerr *UnmarshalError
+	if !errors.As(err, &uerr) || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("unmarshal error, want *UnmarshalError wrapping ErrNotFound, got %v", err)
+	}
+	if want := ".Lists[0].Items[0].Rel"; uerr.Path != want {
+		t.Errorf("unmarshal error path, want %q, got %q", want, uerr.Path)
+	}
+
+	var bad struct {
+		ID int `soup:"a,attr=id"`
+	}
+	if err := Unmarshal(root, &bad); err == nil || !strings.Contains(err.Error(), "ID") {
+		t.Errorf("unmarshal convert error, got %v", err)
+	}
+	var invalid struct {
+		ID string `soup:"a[,attr=id"`
+	}
+	var serr *SelectorError
+	if err := Unmarshal(root, &invalid); !errors.As(err, &serr) {
+		t.Errorf("unmarshal selector error, want *SelectorError, got %v", err)
+	}
+	var all struct {
+		Link string `soup:"a,all"`
+	}
+	if err := Unmarshal(root, &all); err == nil || !strings.Contains(err.Error(), "Link") {
+		t.Errorf("unmarshal all to non slice field, want error, got %v", err)
+	}
+	var required struct {
+		Titles []string `soup:"a,attr=title,required"`
+	}
+	if err := Unmarshal(parseBody(t, `<a title="x">a</a><a>b</a>`), &required); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unmarshal required attributes, want ErrNotFound, got %v", err)
+	}
+	if err := Unmarshal(root, bad); err == nil {
+		t.Errorf("unmarshal to non pointer, want error, got nil")
+	}
+}
@@ -0,0 +1,130 @@
+package supersimplesoup
+
+import (
+	"golang.org/x/net/html"
+)
+
+// WalkOrder is the order in which WalkWith visits the nodes.
+type WalkOrder int
+
+const (
+	// PreOrder visits a node before its child nodes, which is the order of Walk.
+	PreOrder WalkOrder = iota
+	// PostOrder visits a node after its child nodes.
+	PostOrder
+	// BreadthFirst visits the nodes level by level, the shallower nodes go first.
+	BreadthFirst
+)
+
+// WalkOptions controls how WalkWith walks the node tree.
+type WalkOptions struct {
+	// Order is the order in which the nodes are visited.
+	Order WalkOrder
+	// MaxDepth is the max depth of the visited nodes, relative to the root whose depth is 0.
+	// Zero means no limit.
+	MaxDepth int
+	// ElementOnly makes only the element nodes visited.
+	ElementOnly bool
+	// SkipText makes the text nodes not visited.
+	SkipText bool
+	// SkipComment makes the comment nodes not visited.
+	SkipComment bool
+}
+
+// WalkDepthFunc is the type of the function called by WalkWith to visit each node with its depth relative to the root.
+//
+// The error result returned by the function controls how WalkWith continues, the same as WalkFunc.
+// In the PostOrder walk, the child nodes have already been visited when the function is called,
+// so returning SkipNode has no effect.
+type WalkDepthFunc func(node *Node, depth int) error
+
+// WalkWith walks the node tree rooted at root with the specified options, calling fn for each visited node in the tree.
+//
+// The nodes not visited because of the options are still walked through, so their child nodes may be visited.
+func WalkWith(root *Node, opts WalkOptions, fn WalkDepthFunc) error {
+	var err error
+	if opts.Order == BreadthFirst {
+		err = walkBreadthFirst(root, opts, fn)
+	} else {
+		err = walkDepthFirst(root, 0, opts, fn)
+	}
+	if err == SkipNode || err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+// WalkWith walks the node tree rooted at this node with the specified options, calling fn for each visited node in the tree.
+func (n *Node) WalkWith(opts WalkOptions, fn WalkDepthFunc) error {
+	return WalkWith(n, opts, fn)
+}
+
+func (opts *WalkOptions) visit(node *Node) bool {
+	switch node.Type {
+	case html.ElementNode:
+		return true
+	case html.TextNode:
+		return !opts.ElementOnly && !opts.SkipText
+	case html.CommentNode:
+		return !opts.ElementOnly && !opts.SkipComment
+	}
+	return !opts.ElementOnly
+}
+
+func (opts *WalkOptions) descend(depth int) bool {
+	return opts.MaxDepth <= 0 || depth < opts.MaxDepth
+}
+
+func walkDepthFirst(node *Node, depth int, opts WalkOptions, fn WalkDepthFunc) error {
+	if node == nil {
+		return nil
+	}
+	visit := opts.visit(node)
+	if visit && opts.Order == PreOrder {
+		if err := fn(node, depth); err != nil {
+			return err
+		}
+	}
+	if opts.descend(depth) {
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			if err := walkDepthFirst((*Node)(c), depth+1, opts, fn); err != nil {
+				if err != SkipNode {
+					return err
+				}
+			}
+		}
+	}
+	if visit && opts.Order == PostOrder {
+		return fn(node, depth)
+	}
+	return nil
+}
+
+func walkBreadthFirst(root *Node, opts WalkOptions, fn WalkDepthFunc) error {
+	type item struct {
+		node  *Node
+		depth int
+	}
+	if root == nil {
+		return nil
+	}
+	queue := []item{{root, 0}}
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		if opts.visit(it.node) {
+			if err := fn(it.node, it.depth); err != nil {
+				if err != SkipNode {
+					return err
+				}
+				continue
+			}
+		}
+		if opts.descend(it.depth) {
+			for c := it.node.FirstChild; c != nil; c = c.NextSibling {
+				queue = append(queue, item{(*Node)(c), it.depth + 1})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package supersimplesoup
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWalkWith(t *testing.T) {
+	ul := root.Query("ul", "id", "ul-id-1")
+	tests := []struct {
+		name string
+		opts WalkOptions
+		want []string
+	}{
+		{"pre-order", WalkOptions{ElementOnly: true}, []string{"ul-id-1", "li-id-1", "a-id-1", "a-id-2", "li-id-2", "a-id-3", "a-id-4"}},
+		{"post-order", WalkOptions{Order: PostOrder, ElementOnly: true}, []string{"a-id-1", "a-id-2", "li-id-1", "a-id-3", "a-id-4", "li-id-2", "ul-id-1"}},
+		{"breadth-first", WalkOptions{Order: BreadthFirst, ElementOnly: true}, []string{"ul-id-1", "li-id-1", "li-id-2", "a-id-1", "a-id-2", "a-id-3", "a-id-4"}},
+		{"max-depth", WalkOptions{MaxDepth: 1, ElementOnly: true}, []string{"ul-id-1", "li-id-1", "li-id-2"}},
+		{"breadth-first-max-depth", WalkOptions{Order: BreadthFirst, MaxDepth: 1, ElementOnly: true}, []string{"ul-id-1", "li-id-1", "li-id-2"}},
+	}
+
+	for _, test := range tests {
+		var got []string
+		ul.WalkWith(test.opts, func(node *Node, depth int) error {
+			got = append(got, node.ID())
+			return nil
+		})
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("`%s` element ids, want %v, got %v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestWalkWithNodeTypes(t *testing.T) {
+	li := root.Query("li")
+	tests := []struct {
+		name string
+		opts WalkOptions
+		want int
+	}{
+		{"all", WalkOptions{}, 8},
+		{"skip-text", WalkOptions{SkipText: true}, 3},
+		{"element-only", WalkOptions{ElementOnly: true}, 3},
+	}
+
+	for _, test := range tests {
+		got := 0
+		li.WalkWith(test.opts, func(node *Node, depth int) error {
+			got++
+			return nil
+		})
+		if test.want != got {
+			t.Errorf("`%s` node count, want %d, got %d", test.name, test.want, got)
+		}
+	}
+}
+
+func TestWalkWithSkip(t *testing.T) {
+	skipLi := func(node *Node, depth int) error {
+		if node.Data == "li" {
+			return SkipNode
+		}
+		return nil
+	}
+	for _, order := range []WalkOrder{PreOrder, BreadthFirst} {
+		got := 0
+		WalkWith(root, WalkOptions{Order: order, ElementOnly: true}, func(node *Node, depth int) error {
+			got++
+			return skipLi(node, depth)
+		})
+		if want := 11; want != got {
+			t.Errorf("order %d skip node count, want %d, got %d", order, want, got)
+		}
+	}
+
+	for _, order := range []WalkOrder{PreOrder, PostOrder, BreadthFirst} {
+		var shallowest *Node
+		err := WalkWith(root, WalkOptions{Order: order, ElementOnly: true}, func(node *Node, depth int) error {
+			if node.Data == "a" || node.Data == "li" {
+				shallowest = node
+				return SkipAll
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("order %d skip all, want no error, got %v", order, err)
+		}
+		want := map[WalkOrder]string{PreOrder: "li-id-1", PostOrder: "a-id-1", BreadthFirst: "li-id-1"}[order]
+		if got := shallowest.ID(); want != got {
+			t.Errorf("order %d first matched id, want %q, got %q", order, want, got)
+		}
+	}
+
+	errStop := errors.New("stop")
+	if err := WalkWith(root, WalkOptions{Order: PostOrder}, func(node *Node, depth int) error {
+		return errStop
+	}); err != errStop {
+		t.Errorf("walk error, want %v, got %v", errStop, err)
+	}
+}
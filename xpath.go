@@ -0,0 +1,1176 @@
+package supersimplesoup
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XPathError describes an XPath expression which failed to parse.
+type XPathError struct {
+	Expr   string // the expression source
+	Offset int    // byte offset in the expression source where the error occurred
+	Msg    string // description of the error
+}
+
+func (e *XPathError) Error() string {
+	return fmt.Sprintf("invalid xpath `%s` at offset %d: %s", e.Expr, e.Offset, e.Msg)
+}
+
+// XPath evaluates the XPath 1.0 expression with this node as the context node.
+//
+// The result is Nodes for a node-set expression, or string, float64 or bool for a scalar expression.
+// The attribute nodes of a node-set are returned as detached text nodes holding the attribute value.
+func (n *Node) XPath(expr string) (any, error) {
+	if n == nil {
+		return nil, fmt.Errorf("not allow to evaluate xpath on a blank node")
+	}
+	e, err := parseXPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	v, err := evalXPath(n, e)
+	if err != nil {
+		return nil, err
+	}
+	if ns, ok := v.([]xnode); ok {
+		return xnodesToNodes(ns), nil
+	}
+	return v, nil
+}
+
+// XPathAll returns the nodes selected by the XPath 1.0 expression with this node as the context node.
+//
+// It returns an error if the expression is invalid or does not evaluate to a node-set.
+func (n *Node) XPathAll(expr string) (Nodes, error) {
+	v, err := n.XPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	if ns, ok := v.(Nodes); ok {
+		return ns, nil
+	}
+	return nil, fmt.Errorf("xpath `%s` does not evaluate to a node-set", expr)
+}
+
+// xnode is a node in the XPath data model. The attributes are not nodes in the parse tree,
+// so an attribute node is represented by its owner element and the index in the owner attributes.
+type xnode struct {
+	node *Node
+	attr int
+}
+
+func (x xnode) isAttr() bool {
+	return x.attr >= 0
+}
+
+func xnodesToNodes(xs []xnode) Nodes {
+	var ns Nodes
+	for _, x := range xs {
+		if x.isAttr() {
+			ns = append(ns, &Node{Type: html.TextNode, Data: x.node.Attr[x.attr].Val})
+		} else {
+			ns = append(ns, x.node)
+		}
+	}
+	return ns
+}
+
+// xpathRuntimeError is raised by panic during the evaluation and recovered by evalXPath.
+type xpathRuntimeError struct {
+	err error
+}
+
+type xpathContext struct {
+	node  xnode
+	pos   int
+	size  int
+	order map[*Node]int
+}
+
+func evalXPath(n *Node, e xpathExpr) (v any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rerr, ok := r.(xpathRuntimeError); ok {
+				err = rerr.err
+				return
+			}
+			panic(r)
+		}
+	}()
+	c := &xpathContext{node: xnode{node: n, attr: -1}, pos: 1, size: 1}
+	return e.eval(c), nil
+}
+
+func (c *xpathContext) with(x xnode, pos, size int) *xpathContext {
+	return &xpathContext{node: x, pos: pos, size: size, order: c.order}
+}
+
+// sort sorts the nodes in document order and removes the duplicates.
+func (c *xpathContext) sort(xs []xnode) []xnode {
+	if len(xs) < 2 {
+		return xs
+	}
+	if c.order == nil {
+		c.order = make(map[*Node]int)
+		i := 0
+		Walk(rootNode(c.node.node), func(node *Node) error {
+			c.order[node] = i
+			i++
+			return nil
+		})
+	}
+	seen := make(map[xnode]bool, len(xs))
+	uniq := xs[:0:0]
+	for _, x := range xs {
+		if !seen[x] {
+			seen[x] = true
+			uniq = append(uniq, x)
+		}
+	}
+	sort.SliceStable(uniq, func(i, j int) bool {
+		a, b := c.order[uniq[i].node], c.order[uniq[j].node]
+		if a != b {
+			return a < b
+		}
+		return uniq[i].attr < uniq[j].attr
+	})
+	return uniq
+}
+
+func xpathStringValue(x xnode) string {
+	if x.isAttr() {
+		return x.node.Attr[x.attr].Val
+	}
+	switch x.node.Type {
+	case html.ElementNode, html.DocumentNode:
+		return x.node.FullText()
+	}
+	return x.node.Data
+}
+
+func xpathString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []xnode:
+		if len(v) == 0 {
+			return ""
+		}
+		return xpathStringValue(v[0])
+	}
+	return ""
+}
+
+var xpathNumberRegexp = regexp.MustCompile(`^\s*-?(\d+(\.\d*)?|\.\d+)\s*$`)
+
+func xpathNumber(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case string:
+		if !xpathNumberRegexp.MatchString(v) {
+			return math.NaN()
+		}
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	case []xnode:
+		return xpathNumber(xpathString(v))
+	}
+	return math.NaN()
+}
+
+func xpathBoolean(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	case []xnode:
+		return len(v) > 0
+	}
+	return false
+}
+
+func xpathNodeSet(v any) []xnode {
+	if ns, ok := v.([]xnode); ok {
+		return ns
+	}
+	panic(xpathRuntimeError{fmt.Errorf("xpath expression does not evaluate to a node-set")})
+}
+
+type xpathExpr interface {
+	eval(c *xpathContext) any
+}
+
+type xpathLiteral struct {
+	val any
+}
+
+func (e *xpathLiteral) eval(c *xpathContext) any {
+	return e.val
+}
+
+type xpathNeg struct {
+	expr xpathExpr
+}
+
+func (e *xpathNeg) eval(c *xpathContext) any {
+	return -xpathNumber(e.expr.eval(c))
+}
+
+type xpathBinary struct {
+	op          string
+	left, right xpathExpr
+}
+
+func (e *xpathBinary) eval(c *xpathContext) any {
+	switch e.op {
+	case "or":
+		return xpathBoolean(e.left.eval(c)) || xpathBoolean(e.right.eval(c))
+	case "and":
+		return xpathBoolean(e.left.eval(c)) && xpathBoolean(e.right.eval(c))
+	case "=", "!=", "<", "<=", ">", ">=":
+		return xpathCompare(e.op, e.left.eval(c), e.right.eval(c))
+	case "|":
+		left, right := xpathNodeSet(e.left.eval(c)), xpathNodeSet(e.right.eval(c))
+		return c.sort(append(append([]xnode{}, left...), right...))
+	}
+	a, b := xpathNumber(e.left.eval(c)), xpathNumber(e.right.eval(c))
+	switch e.op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "div":
+		return a / b
+	case "mod":
+		return math.Mod(a, b)
+	}
+	return math.NaN()
+}
+
+func xpathCompare(op string, a, b any) bool {
+	as, aok := a.([]xnode)
+	bs, bok := b.([]xnode)
+	switch {
+	case aok && bok:
+		for _, x := range as {
+			for _, y := range bs {
+				if xpathCompareAtom(op, xpathStringValue(x), xpathStringValue(y)) {
+					return true
+				}
+			}
+		}
+		return false
+	case aok:
+		if _, ok := b.(bool); ok {
+			return xpathCompareAtom(op, len(as) > 0, b)
+		}
+		for _, x := range as {
+			if xpathCompareAtom(op, xpathStringValue(x), b) {
+				return true
+			}
+		}
+		return false
+	case bok:
+		if _, ok := a.(bool); ok {
+			return xpathCompareAtom(op, a, len(bs) > 0)
+		}
+		for _, y := range bs {
+			if xpathCompareAtom(op, a, xpathStringValue(y)) {
+				return true
+			}
+		}
+		return false
+	}
+	return xpathCompareAtom(op, a, b)
+}
+
+func xpathCompareAtom(op string, a, b any) bool {
+	if op == "=" || op == "!=" {
+		var eq bool
+		_, abool := a.(bool)
+		_, bbool := b.(bool)
+		_, anum := a.(float64)
+		_, bnum := b.(float64)
+		switch {
+		case abool || bbool:
+			eq = xpathBoolean(a) == xpathBoolean(b)
+		case anum || bnum:
+			eq = xpathNumber(a) == xpathNumber(b)
+		default:
+			eq = xpathString(a) == xpathString(b)
+		}
+		return eq == (op == "=")
+	}
+	x, y := xpathNumber(a), xpathNumber(b)
+	switch op {
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	case ">=":
+		return x >= y
+	}
+	return false
+}
+
+type xpathFilter struct {
+	expr  xpathExpr
+	preds []xpathExpr
+}
+
+func (e *xpathFilter) eval(c *xpathContext) any {
+	v := e.expr.eval(c)
+	if len(e.preds) == 0 {
+		return v
+	}
+	return xpathPredicates(c, xpathNodeSet(v), e.preds)
+}
+
+func xpathPredicates(c *xpathContext, xs []xnode, preds []xpathExpr) []xnode {
+	for _, pred := range preds {
+		var kept []xnode
+		for i, x := range xs {
+			v := pred.eval(c.with(x, i+1, len(xs)))
+			if f, ok := v.(float64); ok {
+				if f == float64(i+1) {
+					kept = append(kept, x)
+				}
+			} else if xpathBoolean(v) {
+				kept = append(kept, x)
+			}
+		}
+		xs = kept
+	}
+	return xs
+}
+
+type xpathPath struct {
+	start xpathExpr // nil for the location path starting from the context node
+	abs   bool
+	steps []*xpathStep
+}
+
+func (e *xpathPath) eval(c *xpathContext) any {
+	var xs []xnode
+	switch {
+	case e.start != nil:
+		xs = xpathNodeSet(e.start.eval(c))
+	case e.abs:
+		xs = []xnode{{node: rootNode(c.node.node), attr: -1}}
+	default:
+		xs = []xnode{c.node}
+	}
+	for _, step := range e.steps {
+		var next []xnode
+		for _, x := range xs {
+			next = append(next, step.eval(c, x)...)
+		}
+		xs = c.sort(next)
+	}
+	return xs
+}
+
+type xpathStep struct {
+	axis  string
+	test  string // `*`, a name, or a node type test such as `text()`
+	preds []xpathExpr
+}
+
+func (s *xpathStep) eval(c *xpathContext, x xnode) []xnode {
+	var xs []xnode
+	for _, y := range xpathAxis(s.axis, x) {
+		if s.matchTest(y) {
+			xs = append(xs, y)
+		}
+	}
+	return xpathPredicates(c, xs, s.preds)
+}
+
+func (s *xpathStep) matchTest(x xnode) bool {
+	switch s.test {
+	case "node()":
+		return true
+	case "text()":
+		return !x.isAttr() && x.node.IsTextNode()
+	case "comment()":
+		return !x.isAttr() && x.node.Type == html.CommentNode
+	case "processing-instruction()":
+		return false
+	}
+	if s.axis == "attribute" {
+		return x.isAttr() && (s.test == "*" || s.test == x.node.Attr[x.attr].Key)
+	}
+	return !x.isAttr() && x.node.IsElementNode() && (s.test == "*" || s.test == x.node.Data)
+}
+
+// xpathAxis returns the nodes on the axis from x in the axis order.
+func xpathAxis(axis string, x xnode) (xs []xnode) {
+	n := x.node
+	add := func(n *Node) {
+		xs = append(xs, xnode{node: n, attr: -1})
+	}
+	descendants := func(n *Node) {
+		Walk(n, func(node *Node) error {
+			if node != n {
+				add(node)
+			}
+			return nil
+		})
+	}
+	switch axis {
+	case "self":
+		xs = append(xs, x)
+	case "attribute":
+		if !x.isAttr() && n.IsElementNode() {
+			for i := range n.Attr {
+				xs = append(xs, xnode{node: n, attr: i})
+			}
+		}
+	case "namespace":
+	case "parent":
+		if x.isAttr() {
+			add(n)
+		} else if p := n.ParentNode(); p != nil {
+			add(p)
+		}
+	case "ancestor", "ancestor-or-self":
+		if axis == "ancestor-or-self" {
+			xs = append(xs, x)
+		}
+		if x.isAttr() {
+			add(n)
+		}
+		for p := n.ParentNode(); p != nil; p = p.ParentNode() {
+			add(p)
+		}
+	}
+	if x.isAttr() {
+		switch axis {
+		case "descendant-or-self":
+			xs = append(xs, x)
+		case "following":
+			descendants(n)
+			for p := n; p != nil; p = p.ParentNode() {
+				for s := p.NextSiblingNode(); s != nil; s = s.NextSiblingNode() {
+					add(s)
+					descendants(s)
+				}
+			}
+		case "preceding":
+			// The owner element is an ancestor of the attribute, so they share the preceding nodes.
+			return xpathAxis(axis, xnode{node: n, attr: -1})
+		}
+		return
+	}
+	switch axis {
+	case "child":
+		for c := n.FirstChildNode(); c != nil; c = c.NextSiblingNode() {
+			add(c)
+		}
+	case "descendant":
+		descendants(n)
+	case "descendant-or-self":
+		add(n)
+		descendants(n)
+	case "following-sibling":
+		for s := n.NextSiblingNode(); s != nil; s = s.NextSiblingNode() {
+			add(s)
+		}
+	case "preceding-sibling":
+		for s := n.PrevSiblingNode(); s != nil; s = s.PrevSiblingNode() {
+			add(s)
+		}
+	case "following":
+		for p := n; p != nil; p = p.ParentNode() {
+			for s := p.NextSiblingNode(); s != nil; s = s.NextSiblingNode() {
+				add(s)
+				descendants(s)
+			}
+		}
+	case "preceding":
+		for p := n; p != nil; p = p.ParentNode() {
+			for s := p.PrevSiblingNode(); s != nil; s = s.PrevSiblingNode() {
+				var sub []xnode
+				Walk(s, func(node *Node) error {
+					sub = append(sub, xnode{node: node, attr: -1})
+					return nil
+				})
+				for i := len(sub) - 1; i >= 0; i-- {
+					xs = append(xs, sub[i])
+				}
+			}
+		}
+	}
+	return
+}
+
+type xpathCall struct {
+	fn   *xpathFunc
+	args []xpathExpr
+}
+
+func (e *xpathCall) eval(c *xpathContext) any {
+	return e.fn.call(c, e.args)
+}
+
+type xpathFunc struct {
+	min, max int // max is -1 for variadic function
+	call     func(c *xpathContext, args []xpathExpr) any
+}
+
+// xpathArgString evaluates the i-th argument to string, or the string-value of the context node if the argument is omitted.
+func xpathArgString(c *xpathContext, args []xpathExpr, i int) string {
+	if i < len(args) {
+		return xpathString(args[i].eval(c))
+	}
+	return xpathStringValue(c.node)
+}
+
+func xpathArgNode(c *xpathContext, args []xpathExpr) (xnode, bool) {
+	if len(args) == 0 {
+		return c.node, true
+	}
+	xs := xpathNodeSet(args[0].eval(c))
+	if len(xs) == 0 {
+		return xnode{}, false
+	}
+	return xs[0], true
+}
+
+func xpathRound(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return math.Floor(f + 0.5)
+}
+
+var xpathFuncs map[string]*xpathFunc
+
+func init() {
+	xpathFuncs = map[string]*xpathFunc{
+		"last": {0, 0, func(c *xpathContext, args []xpathExpr) any {
+			return float64(c.size)
+		}},
+		"position": {0, 0, func(c *xpathContext, args []xpathExpr) any {
+			return float64(c.pos)
+		}},
+		"count": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			return float64(len(xpathNodeSet(args[0].eval(c))))
+		}},
+		"id": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			var ids []string
+			if xs, ok := args[0].eval(c).([]xnode); ok {
+				for _, x := range xs {
+					ids = append(ids, strings.Fields(xpathStringValue(x))...)
+				}
+			} else {
+				ids = strings.Fields(xpathString(args[0].eval(c)))
+			}
+			want := make(map[string]bool)
+			for _, id := range ids {
+				want[id] = true
+			}
+			var xs []xnode
+			Walk(rootNode(c.node.node), func(node *Node) error {
+				if node.IsElementNode() && want[node.ID()] {
+					xs = append(xs, xnode{node: node, attr: -1})
+				}
+				return nil
+			})
+			return xs
+		}},
+		"local-name": {0, 1, func(c *xpathContext, args []xpathExpr) any {
+			x, ok := xpathArgNode(c, args)
+			switch {
+			case !ok:
+				return ""
+			case x.isAttr():
+				return x.node.Attr[x.attr].Key
+			case x.node.IsElementNode():
+				return x.node.Data
+			}
+			return ""
+		}},
+		"name": {0, 1, func(c *xpathContext, args []xpathExpr) any {
+			return xpathFuncs["local-name"].call(c, args)
+		}},
+		"namespace-uri": {0, 1, func(c *xpathContext, args []xpathExpr) any {
+			return ""
+		}},
+		"string": {0, 1, func(c *xpathContext, args []xpathExpr) any {
+			return xpathArgString(c, args, 0)
+		}},
+		"concat": {2, -1, func(c *xpathContext, args []xpathExpr) any {
+			var buf strings.Builder
+			for _, arg := range args {
+				buf.WriteString(xpathString(arg.eval(c)))
+			}
+			return buf.String()
+		}},
+		"starts-with": {2, 2, func(c *xpathContext, args []xpathExpr) any {
+			return strings.HasPrefix(xpathArgString(c, args, 0), xpathArgString(c, args, 1))
+		}},
+		"contains": {2, 2, func(c *xpathContext, args []xpathExpr) any {
+			return strings.Contains(xpathArgString(c, args, 0), xpathArgString(c, args, 1))
+		}},
+		"substring-before": {2, 2, func(c *xpathContext, args []xpathExpr) any {
+			s, sep := xpathArgString(c, args, 0), xpathArgString(c, args, 1)
+			if i := strings.Index(s, sep); i >= 0 {
+				return s[:i]
+			}
+			return ""
+		}},
+		"substring-after": {2, 2, func(c *xpathContext, args []xpathExpr) any {
+			s, sep := xpathArgString(c, args, 0), xpathArgString(c, args, 1)
+			if i := strings.Index(s, sep); i >= 0 {
+				return s[i+len(sep):]
+			}
+			return ""
+		}},
+		"substring": {2, 3, func(c *xpathContext, args []xpathExpr) any {
+			rs := []rune(xpathArgString(c, args, 0))
+			start := xpathRound(xpathNumber(args[1].eval(c)))
+			end := math.Inf(1)
+			if len(args) > 2 {
+				end = start + xpathRound(xpathNumber(args[2].eval(c)))
+			}
+			var buf strings.Builder
+			for i, r := range rs {
+				if p := float64(i + 1); p >= start && p < end {
+					buf.WriteRune(r)
+				}
+			}
+			return buf.String()
+		}},
+		"string-length": {0, 1, func(c *xpathContext, args []xpathExpr) any {
+			return float64(len([]rune(xpathArgString(c, args, 0))))
+		}},
+		"normalize-space": {0, 1, func(c *xpathContext, args []xpathExpr) any {
+			return strings.Join(strings.Fields(xpathArgString(c, args, 0)), " ")
+		}},
+		"translate": {3, 3, func(c *xpathContext, args []xpathExpr) any {
+			from, to := []rune(xpathArgString(c, args, 1)), []rune(xpathArgString(c, args, 2))
+			return strings.Map(func(r rune) rune {
+				for i, f := range from {
+					if f == r {
+						if i < len(to) {
+							return to[i]
+						}
+						return -1
+					}
+				}
+				return r
+			}, xpathArgString(c, args, 0))
+		}},
+		"boolean": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			return xpathBoolean(args[0].eval(c))
+		}},
+		"not": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			return !xpathBoolean(args[0].eval(c))
+		}},
+		"true": {0, 0, func(c *xpathContext, args []xpathExpr) any {
+			return true
+		}},
+		"false": {0, 0, func(c *xpathContext, args []xpathExpr) any {
+			return false
+		}},
+		"lang": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			want := strings.ToLower(xpathString(args[0].eval(c)))
+			for n := c.node.node; n != nil; n = n.ParentNode() {
+				for _, attr := range n.Attr {
+					if attr.Key == "lang" || attr.Key == "xml:lang" {
+						lang := strings.ToLower(attr.Val)
+						return lang == want || strings.HasPrefix(lang, want+"-")
+					}
+				}
+			}
+			return false
+		}},
+		"number": {0, 1, func(c *xpathContext, args []xpathExpr) any {
+			if len(args) == 0 {
+				return xpathNumber(xpathStringValue(c.node))
+			}
+			return xpathNumber(args[0].eval(c))
+		}},
+		"sum": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			sum := 0.0
+			for _, x := range xpathNodeSet(args[0].eval(c)) {
+				sum += xpathNumber(xpathStringValue(x))
+			}
+			return sum
+		}},
+		"floor": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			return math.Floor(xpathNumber(args[0].eval(c)))
+		}},
+		"ceiling": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			return math.Ceil(xpathNumber(args[0].eval(c)))
+		}},
+		"round": {1, 1, func(c *xpathContext, args []xpathExpr) any {
+			return xpathRound(xpathNumber(args[0].eval(c)))
+		}},
+	}
+}
+
+var xpathAxes = map[string]bool{
+	"ancestor": true, "ancestor-or-self": true, "attribute": true, "child": true,
+	"descendant": true, "descendant-or-self": true, "following": true, "following-sibling": true,
+	"namespace": true, "parent": true, "preceding": true, "preceding-sibling": true, "self": true,
+}
+
+var xpathNodeTypes = map[string]bool{
+	"node": true, "text": true, "comment": true, "processing-instruction": true,
+}
+
+const (
+	xpathTokenEOF = iota
+	xpathTokenSymbol
+	xpathTokenName
+	xpathTokenNumber
+	xpathTokenString
+	xpathTokenVar
+)
+
+type xpathToken struct {
+	kind int
+	val  string
+	pos  int
+}
+
+type xpathParser struct {
+	src    string
+	tokens []xpathToken
+	i      int
+}
+
+func parseXPath(src string) (xpathExpr, error) {
+	p := &xpathParser{src: src}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != xpathTokenEOF {
+		return nil, p.errorf(t, "unexpected %q", t.val)
+	}
+	return e, nil
+}
+
+func (p *xpathParser) errorf(t xpathToken, format string, args ...any) error {
+	return &XPathError{Expr: p.src, Offset: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func isXPathNameStart(c byte) bool {
+	return c == '_' || c >= 0x80 || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isXPathNameChar(c byte) bool {
+	return isXPathNameStart(c) || c == '-' || c == '.' || ('0' <= c && c <= '9')
+}
+
+func (p *xpathParser) tokenize() error {
+	s := p.src
+	i := 0
+	// operatorContext reports whether a `*` or a name is an operator according to the preceding token.
+	operatorContext := func() bool {
+		if len(p.tokens) == 0 {
+			return false
+		}
+		t := p.tokens[len(p.tokens)-1]
+		if t.kind != xpathTokenSymbol {
+			return true
+		}
+		switch t.val {
+		case "@", "::", "(", "[", ",", "and", "or", "mod", "div", "*", "/", "//", "|", "+", "-", "=", "!=", "<", "<=", ">", ">=":
+			return false
+		}
+		return true
+	}
+	for i < len(s) {
+		c := s[i]
+		start := i
+		switch {
+		case strings.IndexByte(" \t\r\n", c) >= 0:
+			i++
+			continue
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return &XPathError{Expr: s, Offset: i, Msg: "unterminated string literal"}
+			}
+			p.tokens = append(p.tokens, xpathToken{xpathTokenString, s[i+1 : i+1+end], start})
+			i += end + 2
+			continue
+		case '0' <= c && c <= '9' || c == '.' && i+1 < len(s) && '0' <= s[i+1] && s[i+1] <= '9':
+			for i < len(s) && ('0' <= s[i] && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+			p.tokens = append(p.tokens, xpathToken{xpathTokenNumber, s[start:i], start})
+			continue
+		case c == '$' || isXPathNameStart(c):
+			if c == '$' {
+				i++
+			}
+			for i < len(s) && isXPathNameChar(s[i]) {
+				i++
+			}
+			if i+1 < len(s) && s[i] == ':' && s[i+1] != ':' {
+				i++
+				if s[i] == '*' {
+					i++
+				}
+				for i < len(s) && isXPathNameChar(s[i]) {
+					i++
+				}
+			}
+			name := s[start:i]
+			switch {
+			case c == '$':
+				p.tokens = append(p.tokens, xpathToken{xpathTokenVar, name, start})
+			case operatorContext() && (name == "and" || name == "or" || name == "mod" || name == "div"):
+				p.tokens = append(p.tokens, xpathToken{xpathTokenSymbol, name, start})
+			default:
+				p.tokens = append(p.tokens, xpathToken{xpathTokenName, name, start})
+			}
+			continue
+		case c == '*':
+			i++
+			if operatorContext() {
+				p.tokens = append(p.tokens, xpathToken{xpathTokenSymbol, "*", start})
+			} else {
+				p.tokens = append(p.tokens, xpathToken{xpathTokenName, "*", start})
+			}
+			continue
+		}
+		for _, sym := range []string{"//", "::", "..", "!=", "<=", ">=", "/", "(", ")", "[", "]", ".", "@", ",", "|", "+", "-", "=", "<", ">"} {
+			if strings.HasPrefix(s[i:], sym) {
+				p.tokens = append(p.tokens, xpathToken{xpathTokenSymbol, sym, start})
+				i += len(sym)
+				break
+			}
+		}
+		if i == start {
+			return &XPathError{Expr: s, Offset: i, Msg: fmt.Sprintf("unexpected %q", c)}
+		}
+	}
+	p.tokens = append(p.tokens, xpathToken{xpathTokenEOF, "", len(s)})
+	return nil
+}
+
+func (p *xpathParser) peek() xpathToken {
+	return p.tokens[p.i]
+}
+
+func (p *xpathParser) peekAt(k int) xpathToken {
+	if p.i+k < len(p.tokens) {
+		return p.tokens[p.i+k]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *xpathParser) next() xpathToken {
+	t := p.tokens[p.i]
+	if t.kind != xpathTokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *xpathParser) consume(sym string) bool {
+	if t := p.peek(); t.kind == xpathTokenSymbol && t.val == sym {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *xpathParser) expect(sym string) error {
+	if !p.consume(sym) {
+		t := p.peek()
+		return p.errorf(t, "expected %q", sym)
+	}
+	return nil
+}
+
+// parseBinary parses the left associative binary operators of the same precedence.
+func (p *xpathParser) parseBinary(ops []string, operand func() (xpathExpr, error)) (xpathExpr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		found := false
+		for _, op := range ops {
+			if t.kind == xpathTokenSymbol && t.val == op {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &xpathBinary{op: t.val, left: left, right: right}
+	}
+}
+
+func (p *xpathParser) parseOr() (xpathExpr, error) {
+	return p.parseBinary([]string{"or"}, p.parseAnd)
+}
+
+func (p *xpathParser) parseAnd() (xpathExpr, error) {
+	return p.parseBinary([]string{"and"}, p.parseEquality)
+}
+
+func (p *xpathParser) parseEquality() (xpathExpr, error) {
+	return p.parseBinary([]string{"=", "!="}, p.parseRelational)
+}
+
+func (p *xpathParser) parseRelational() (xpathExpr, error) {
+	return p.parseBinary([]string{"<", "<=", ">", ">="}, p.parseAdditive)
+}
+
+func (p *xpathParser) parseAdditive() (xpathExpr, error) {
+	return p.parseBinary([]string{"+", "-"}, p.parseMultiplicative)
+}
+
+func (p *xpathParser) parseMultiplicative() (xpathExpr, error) {
+	return p.parseBinary([]string{"*", "div", "mod"}, p.parseUnary)
+}
+
+func (p *xpathParser) parseUnary() (xpathExpr, error) {
+	if p.consume("-") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &xpathNeg{expr: e}, nil
+	}
+	return p.parseBinary([]string{"|"}, p.parsePath)
+}
+
+// isStepStart reports whether the next tokens start a location step rather than a filter expression.
+func (p *xpathParser) isStepStart() bool {
+	t := p.peek()
+	switch t.kind {
+	case xpathTokenSymbol:
+		return t.val == "." || t.val == ".." || t.val == "@"
+	case xpathTokenName:
+		if n := p.peekAt(1); n.kind == xpathTokenSymbol && n.val == "(" {
+			return xpathNodeTypes[t.val]
+		}
+		return true
+	}
+	return false
+}
+
+func (p *xpathParser) parsePath() (xpathExpr, error) {
+	path := &xpathPath{}
+	t := p.peek()
+	switch {
+	case t.kind == xpathTokenSymbol && t.val == "/":
+		p.next()
+		path.abs = true
+		if !p.isStepStart() {
+			return path, nil
+		}
+	case t.kind == xpathTokenSymbol && t.val == "//":
+		p.next()
+		path.abs = true
+		path.steps = append(path.steps, &xpathStep{axis: "descendant-or-self", test: "node()"})
+	case p.isStepStart():
+	default:
+		filter, err := p.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		t := p.peek()
+		if t.kind != xpathTokenSymbol || (t.val != "/" && t.val != "//") {
+			return filter, nil
+		}
+		path.start = filter
+		p.next()
+		if t.val == "//" {
+			path.steps = append(path.steps, &xpathStep{axis: "descendant-or-self", test: "node()"})
+		}
+	}
+	for {
+		step, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		path.steps = append(path.steps, step)
+		if p.consume("//") {
+			path.steps = append(path.steps, &xpathStep{axis: "descendant-or-self", test: "node()"})
+		} else if !p.consume("/") {
+			return path, nil
+		}
+	}
+}
+
+func (p *xpathParser) parseStep() (*xpathStep, error) {
+	if p.consume(".") {
+		return &xpathStep{axis: "self", test: "node()"}, nil
+	}
+	if p.consume("..") {
+		return &xpathStep{axis: "parent", test: "node()"}, nil
+	}
+	step := &xpathStep{axis: "child"}
+	if p.consume("@") {
+		step.axis = "attribute"
+	} else if t, n := p.peek(), p.peekAt(1); t.kind == xpathTokenName && n.kind == xpathTokenSymbol && n.val == "::" {
+		if !xpathAxes[t.val] {
+			return nil, p.errorf(t, "unknown axis %q", t.val)
+		}
+		step.axis = t.val
+		p.next()
+		p.next()
+	}
+	t := p.next()
+	if t.kind != xpathTokenName {
+		return nil, p.errorf(t, "expected node test")
+	}
+	if xpathNodeTypes[t.val] && p.consume("(") {
+		if t.val == "processing-instruction" && p.peek().kind == xpathTokenString {
+			p.next()
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		step.test = t.val + "()"
+	} else if i := strings.LastIndexByte(t.val, ':'); i >= 0 {
+		step.test = t.val[i+1:]
+	} else {
+		step.test = t.val
+	}
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	step.preds = preds
+	return step, nil
+}
+
+func (p *xpathParser) parsePredicates() (preds []xpathExpr, err error) {
+	for p.consume("[") {
+		pred, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+	return preds, nil
+}
+
+func (p *xpathParser) parseFilter() (xpathExpr, error) {
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	if len(preds) == 0 {
+		return e, nil
+	}
+	return &xpathFilter{expr: e, preds: preds}, nil
+}
+
+func (p *xpathParser) parsePrimary() (xpathExpr, error) {
+	t := p.next()
+	switch t.kind {
+	case xpathTokenString:
+		return &xpathLiteral{val: t.val}, nil
+	case xpathTokenNumber:
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number %q", t.val)
+		}
+		return &xpathLiteral{val: f}, nil
+	case xpathTokenVar:
+		return nil, p.errorf(t, "variable reference %s is not supported", t.val)
+	case xpathTokenName:
+		fn, ok := xpathFuncs[t.val]
+		if !ok {
+			return nil, p.errorf(t, "unknown function %s()", t.val)
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		call := &xpathCall{fn: fn}
+		if !p.consume(")") {
+			for {
+				arg, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				call.args = append(call.args, arg)
+				if p.consume(")") {
+					break
+				}
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(call.args) < fn.min || (fn.max >= 0 && len(call.args) > fn.max) {
+			return nil, p.errorf(t, "wrong number of arguments to %s()", t.val)
+		}
+		return call, nil
+	case xpathTokenSymbol:
+		if t.val == "(" {
+			e, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+	}
+	if t.kind == xpathTokenEOF {
+		return nil, p.errorf(t, "unexpected end of expression")
+	}
+	return nil, p.errorf(t, "unexpected %q", t.val)
+}
@@ -0,0 +1,132 @@
+package supersimplesoup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestXPathAll(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"//ul[@id='ul-id-2']/li[2]/a", []string{"a-id-7", "a-id-8"}},
+		{"//li[1]/a[last()]", []string{"a-id-2", "a-id-6"}},
+		{"(//a)[position() > 6]", []string{"a-id-7", "a-id-8"}},
+		{"//a[contains(@href, '-3') or text() = 'a-text-4']", []string{"a-id-3", "a-id-4"}},
+		{"//a[@id='a-id-6']/ancestor::*[@id][1]", []string{"li-id-3"}},
+		{"//a[@id='a-id-6']/ancestor::ul", []string{"ul-id-2"}},
+		{"//li[@id='li-id-2']/following-sibling::li", nil},
+		{"//li[@id='li-id-2']/preceding-sibling::*", []string{"li-id-1"}},
+		{"//li[@id='li-id-2']/following::li", []string{"li-id-3", "li-id-4"}},
+		{"//a[@id='a-id-3']/preceding::a[1]", []string{"a-id-2"}},
+		{"//a[@id='a-id-3']/@href/preceding::a", []string{"a-id-1", "a-id-2"}},
+		{"//a[@id='a-id-3']/@href/preceding::*[@id][1]", []string{"a-id-2"}},
+		{"//a[@id='a-id-1']/@href/following::a[1]", []string{"a-id-2"}},
+		{"//ul[2]/li/a[1] | //ul[1]/li[1]/a[2]", []string{"a-id-2", "a-id-5", "a-id-7"}},
+		{"//li[count(a) = 2 and starts-with(@class, 'li-class-2')]", []string{"li-id-3", "li-id-4"}},
+		{"//*[@id='a-id-1']/../..", []string{"ul-id-1"}},
+		{"id('a-id-8 li-id-1')", []string{"li-id-1", "a-id-8"}},
+	}
+
+	for _, test := range tests {
+		nodes, err := root.XPathAll(test.expr)
+		if err != nil {
+			t.Errorf("`%s` xpath error, %v", test.expr, err)
+			continue
+		}
+		var got []string
+		for _, node := range nodes {
+			got = append(got, node.ID())
+		}
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("`%s` element attribute id, want %v, got %v", test.expr, test.want, got)
+		}
+	}
+}
+
+func TestXPath(t *testing.T) {
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{"count(//a)", 8.0},
+		{"count(//li/a[@class='a-class-1']) * 2 div 4 - 1", 1.0},
+		{"-7 mod 3", -1.0},
+		{"string(//title)", "supersimplesoup"},
+		{"normalize-space(//li[1])", "a-text-1 a-text-2"},
+		{"concat(substring-before('a-b', '-'), substring('12345', 2, 3))", "a234"},
+		{"translate('abc', 'ab', 'A')", "Ac"},
+		{"string(//ul[2]/@title)", "ul-title-2"},
+		{"//a[1]/@href = 'a-href-5'", true},
+		{"count(//a[@id='a-id-1']/@href/self::node())", 1.0},
+		{"count(//a[@id='a-id-1']/@href/descendant-or-self::node())", 1.0},
+		{"string(//a[@id='a-id-1']/@href//.)", "a-href-1"},
+		{"count(//a[@id='a-id-1']/@href/descendant::node())", 0.0},
+		{"boolean(//table)", false},
+		{"round(2.5) + floor(-1.5) + ceiling(1.2)", 3.0},
+		{"string(1 div 0)", "Infinity"},
+		{"sum(//li/@id)", nil},
+	}
+
+	for _, test := range tests {
+		got, err := root.XPath(test.expr)
+		if err != nil {
+			t.Errorf("`%s` xpath error, %v", test.expr, err)
+			continue
+		}
+		if f, ok := got.(float64); ok && f != f {
+			got = nil
+		}
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("`%s` xpath result, want %#v, got %#v", test.expr, test.want, got)
+		}
+	}
+
+	nodes, err := root.XPathAll("//ul/@id")
+	if err != nil {
+		t.Fatalf("`%s` xpath error, %v", "//ul/@id", err)
+	}
+	var got []string
+	for _, node := range nodes {
+		got = append(got, node.Data)
+	}
+	if want := []string{"ul-id-1", "ul-id-2"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("`%s` attribute values, want %v, got %v", "//ul/@id", want, got)
+	}
+}
+
+func TestXPathError(t *testing.T) {
+	tests := []struct {
+		expr   string
+		offset int
+	}{
+		{"//a[", 4},
+		{"//a[@id='x'", 11},
+		{"foo(1)", 0},
+		{"count()", 0},
+		{"//bogus::a", 2},
+		{"$var", 0},
+		{"'abc", 0},
+		{"//a]", 3},
+	}
+
+	for _, test := range tests {
+		_, err := root.XPath(test.expr)
+		xerr, ok := err.(*XPathError)
+		if !ok {
+			t.Errorf("`%s` xpath, want *XPathError, got %v", test.expr, err)
+			continue
+		}
+		if test.offset != xerr.Offset {
+			t.Errorf("`%s` xpath error offset, want %d, got %d", test.expr, test.offset, xerr.Offset)
+		}
+	}
+
+	if _, err := root.XPathAll("count(//a)"); err == nil {
+		t.Errorf("`%s` xpath all, want error, got nil", "count(//a)")
+	}
+	if _, err := root.XPath("'a'/b"); err == nil {
+		t.Errorf("`%s` xpath, want error, got nil", "'a'/b")
+	}
+}